@@ -29,11 +29,13 @@ func parseError(input string) (error, error) {
 		e.Kind = &kind
 	}
 	if code, ok := v["Code"]; ok {
-		code, err := errors.ParseCode(code.(string))
-		if err != nil {
-			return nil, err
+		name := code.(string)
+		code, err := errors.ParseCode(name)
+		if err == nil {
+			e.Code = &code
+		} else {
+			e.UserCode = &name
 		}
-		e.Code = &code
 	}
 	if pos, ok := v["Pos"]; ok {
 		pos := pos.(map[string]any)
@@ -112,5 +114,9 @@ func parseError(input string) (error, error) {
 		}
 		e.GotParam = gotParam
 	}
+	if exitCode, ok := v["ExitCode"]; ok {
+		exitCode := int(exitCode.(float64))
+		e.ExitCode = &exitCode
+	}
 	return &e, nil
 }
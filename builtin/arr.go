@@ -167,6 +167,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewArr(
@@ -215,6 +216,7 @@ var ArrFuncers = []shapes.Funcer{
 				Description: "a function to apply to each element of the input",
 				Params:      nil,
 				OutputType:  types.NewVar("B", types.Any{}),
+				Callback:    true,
 			},
 		},
 		OutputType:        types.NewArr(types.NewVar("B", types.Any{})),
@@ -345,6 +347,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -403,6 +406,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -452,6 +456,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -501,6 +506,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -559,6 +565,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -608,6 +615,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewUnion(
@@ -647,6 +655,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("next", "the next element", types.NewVar("A", types.Any{})),
 				},
 				OutputType: types.NewVar("B", types.Any{}),
+				Callback:   true,
 			},
 		},
 		OutputType:        types.NewVar("B", types.Any{}),
@@ -883,6 +892,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType:        types.NewArr(types.NewVar("B", types.Any{})),
@@ -1000,6 +1010,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("A", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 			params.SimpleParam("default", "default value to return if the input is empty", types.NewVar("B", types.Any{})),
 		},
@@ -1031,6 +1042,7 @@ var ArrFuncers = []shapes.Funcer{
 				Description: `funcer that maps input elements to values by which they will be compared`,
 				Params:      nil,
 				OutputType:  types.NewVar("B", types.Any{}),
+				Callback:    true,
 			},
 			{
 				InputType:   types.NewVar("B", types.Any{}),
@@ -1040,6 +1052,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("B", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 			params.SimpleParam("default", "default value to return if the input is empty", types.NewVar("C", types.Any{})),
 		},
@@ -1071,6 +1084,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("A", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 			params.SimpleParam("default", "default value to return if the input is empty", types.NewVar("B", types.Any{})),
 		},
@@ -1099,6 +1113,7 @@ var ArrFuncers = []shapes.Funcer{
 				Description: `funcer that maps input elements to values by which they will be compared`,
 				Params:      nil,
 				OutputType:  types.NewVar("B", types.Any{}),
+				Callback:    true,
 			},
 			{
 				InputType:   types.NewVar("B", types.Any{}),
@@ -1108,6 +1123,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("B", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 			params.SimpleParam("default", "default value to return if the input is empty", types.NewVar("C", types.Any{})),
 		},
@@ -1342,6 +1358,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("A", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 		},
 		OutputType:        types.NewArr(types.NewVar("A", types.Any{})),
@@ -1385,6 +1402,7 @@ var ArrFuncers = []shapes.Funcer{
 				Description: `funcer that maps input elements to values by which they will be compared`,
 				Params:      nil,
 				OutputType:  types.NewVar("B", types.Any{}),
+				Callback:    true,
 			},
 			{
 				InputType:   types.NewVar("B", types.Any{}),
@@ -1394,6 +1412,7 @@ var ArrFuncers = []shapes.Funcer{
 					params.SimpleParam("other", "", types.NewVar("B", types.Any{})),
 				},
 				OutputType: types.Bool{},
+				Callback:   true,
 			},
 		},
 		OutputType:        types.NewArr(types.NewVar("A", types.Any{})),
@@ -1527,6 +1546,7 @@ var ArrFuncers = []shapes.Funcer{
 						Rest: types.Any{},
 					},
 				),
+				Callback: true,
 			},
 		},
 		OutputType: types.NewArr(
@@ -1,14 +1,143 @@
 package builtin
 
 import (
+	"math"
+	"regexp"
+
 	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/params"
 	"github.com/texttheater/bach/shapes"
 	"github.com/texttheater/bach/states"
 	"github.com/texttheater/bach/types"
 )
 
+// errorObjType is the type of the objects that catch passes to its handler.
+var errorObjType = types.Obj{
+	Props: map[string]types.Type{
+		"kind":    types.Str{},
+		"code":    types.Str{},
+		"message": types.Str{},
+		"value":   types.Any{},
+	},
+	Rest: types.Void{},
+}
+
 var ControlFuncers = []shapes.Funcer{
+	shapes.Funcer{
+		Summary:          "Aborts with an error message if a condition does not hold",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "any value",
+		Name:             "assert",
+		Params: []*params.Param{
+			{
+				InputType:   types.NewVar("A", types.Any{}),
+				Name:        "predicate",
+				Description: "a condition that the input must satisfy",
+				Params:      nil,
+				OutputType:  types.Bool{},
+			},
+			{
+				InputType:   types.NewVar("A", types.Any{}),
+				Name:        "message",
+				Description: "a message describing the problem if the condition does not hold",
+				Params:      nil,
+				OutputType:  types.Str{},
+			},
+		},
+		OutputType:        types.NewVar("A", types.Any{}),
+		OutputDescription: "the input value, if it satisfies predicate",
+		Notes:             "If the input does not satisfy predicate, a value error with code AssertionFailed is raised. Like predicate, message receives the input value as input, so it can refer to the value.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				inputValue, err := inputState.Thunk.Eval()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				ok, err := args[0](inputState, nil).Thunk.EvalBool()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				if ok {
+					return states.ThunkFromValue(inputValue)
+				}
+				message, err := args[1](inputState, nil).Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				return states.ThunkFromError(errors.ValueError(
+					errors.Code(errors.AssertionFailed),
+					errors.Pos(pos),
+					errors.GotValue(inputValue),
+					errors.Message(message),
+				))
+			})
+		},
+		Examples: []shapes.Example{
+			{`5 assert(>0, "must be positive")`, `Num`, `5`, nil},
+			{`-1 assert(>0, "must be positive")`, ``, ``, errors.ValueError(
+				errors.Code(errors.AssertionFailed),
+				errors.Pos(lexer.Position{Offset: 3, Line: 1, Column: 4}),
+				errors.GotValue(states.NumValue(-1)),
+				errors.Message("must be positive"),
+			)},
+			{`[1, 2, 3] assert(len ==2, "expected 2 elements, got {len}")`, ``, ``, errors.ValueError(
+				errors.Code(errors.AssertionFailed),
+				errors.Message("expected 2 elements, got 3"),
+			)},
+			{`-1 assert(>0, "must be positive") catch(@message)`, `Num|Str`, `"must be positive"`, nil},
+		},
+		IDs: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Handles value errors",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "a value whose computation may fail",
+		Name:             "catch",
+		Params: []*params.Param{
+			{
+				InputType:   errorObjType,
+				Name:        "handler",
+				Description: "a function that computes a replacement value from the error",
+				Params:      nil,
+				OutputType:  types.NewVar("B", types.Any{}),
+				Callback:    true,
+			},
+		},
+		OutputType: types.NewUnion(
+			types.NewVar("A", types.Any{}),
+			types.NewVar("B", types.Any{}),
+		),
+		OutputDescription: "the input value, or the output of handler if computing the input value failed with a value error",
+		Notes:             "The handler receives an object with the properties kind (always \"Value\"), code (the error code, e.g., \"UnexpectedValue\"), message (a human-readable description), and value (the offending value, or null if there is none). Only errors that occur in computing the input value itself are caught, not errors in computing its array elements or object properties later. To handle errors for individual elements, use catch inside each.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				inputValue, err := inputState.Thunk.Eval()
+				if err == nil {
+					return states.ThunkFromValue(inputValue)
+				}
+				e, ok := err.(*errors.E)
+				if !ok || e.Kind == nil || *e.Kind != errors.ValueKind {
+					return states.ThunkFromError(err)
+				}
+				handlerInputState := inputState.Replace(states.ThunkFromValue(errorObj(e)))
+				return args[0](handlerInputState, nil).Thunk
+			})
+		},
+		Examples: []shapes.Example{
+			{`"{{" parseJSON catch(@code)`, `Any`, `"UnexpectedValue"`, nil},
+			{`"[1, 2]" parseJSON catch(@code)`, `Any`, `[1, 2]`, nil},
+			{`["1", "x", "3"] each(parseInt catch(null))`, `Arr<Num|Null...>`, `[1, null, 3]`, nil},
+			{`[1, 2] get(5) catch(@code)`, `Num|Str`, `"NoSuchIndex"`, nil},
+			{`"boom" parseInt catch(@value)`, `Any`, `"boom"`, nil},
+			{`[1, 2] get(-1) catch(id)`, `Num|Obj<code: Str, kind: Str, message: Str, value: Any, Void>`, `{kind: "Value", code: "BadIndex", message: "Index must be a nonnegative integer.", value: -1}`, nil},
+			{`[1, 2] get(-1) catch(@value fatal)`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.NumValue(-1)),
+			)},
+		},
+		IDs: nil,
+	},
 	shapes.Funcer{
 		Summary:           "Aborts with an error message",
 		InputType:         types.Any{},
@@ -40,6 +169,189 @@ var ControlFuncers = []shapes.Funcer{
 		},
 		IDs: nil,
 	},
+	shapes.Funcer{
+		Summary:          "Aborts with an error message and a custom exit code",
+		InputType:        types.Any{},
+		InputDescription: "any value",
+		Name:             "fatal",
+		Params: []*params.Param{
+			params.SimpleParam("exitCode", "the exit status with which the Bach CLI will terminate", types.Num{}),
+		},
+		OutputType:        types.Void{},
+		OutputDescription: "does not return",
+		Notes:             "Without an exit code, the Bach CLI terminates with exit status 1 on value errors. Exit codes must be integers between 1 and 255.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			inputValue, err := inputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			exitCode, err := exitCodeArg(inputState, args[0], pos)
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromError(
+				errors.ValueError(
+					errors.Code(errors.UnexpectedValue),
+					errors.Pos(pos),
+					errors.GotValue(inputValue),
+					errors.ExitCode(exitCode),
+				),
+			)
+		},
+		Examples: []shapes.Example{
+			{`1 if ==1 then fatal(5) else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Pos(lexer.Position{Offset: 14, Line: 1, Column: 15}),
+				errors.GotValue(states.NumValue(1)),
+				errors.ExitCode(5),
+			)},
+			{`1 if ==1 then fatal(0) else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.NumValue(0)),
+				errors.Message("The exit code must be an integer between 1 and 255."),
+			)},
+			{`1 if ==1 then fatal(256) else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.NumValue(256)),
+			)},
+		},
+		IDs: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Aborts with a custom error message",
+		InputType:        types.Any{},
+		InputDescription: "any value",
+		Name:             "fatal",
+		Params: []*params.Param{
+			params.SimpleParam("message", "a message describing the problem", types.Str{}),
+		},
+		OutputType:        types.Void{},
+		OutputDescription: "does not return",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			inputValue, err := inputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			message, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromError(
+				errors.ValueError(
+					errors.Code(errors.UnexpectedValue),
+					errors.Pos(pos),
+					errors.GotValue(inputValue),
+					errors.Message(message),
+				),
+			)
+		},
+		Examples: []shapes.Example{
+			{`1 if ==1 then fatal("should not be 1") else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Pos(lexer.Position{Offset: 14, Line: 1, Column: 15}),
+				errors.GotValue(states.NumValue(1)),
+				errors.Message("should not be 1"),
+			)},
+		},
+		IDs: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Aborts with a custom error message and exit code",
+		InputType:        types.Any{},
+		InputDescription: "any value",
+		Name:             "fatal",
+		Params: []*params.Param{
+			params.SimpleParam("exitCode", "the exit status with which the Bach CLI will terminate", types.Num{}),
+			params.SimpleParam("message", "a message describing the problem", types.Str{}),
+		},
+		OutputType:        types.Void{},
+		OutputDescription: "does not return",
+		Notes:             "Exit codes must be integers between 1 and 255.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			inputValue, err := inputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			exitCode, err := exitCodeArg(inputState, args[0], pos)
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			message, err := args[1](inputState.Clear(), nil).Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromError(
+				errors.ValueError(
+					errors.Code(errors.UnexpectedValue),
+					errors.Pos(pos),
+					errors.GotValue(inputValue),
+					errors.Message(message),
+					errors.ExitCode(exitCode),
+				),
+			)
+		},
+		Examples: []shapes.Example{
+			{`1 if ==1 then fatal(7, "should not be 1") else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Pos(lexer.Position{Offset: 14, Line: 1, Column: 15}),
+				errors.GotValue(states.NumValue(1)),
+				errors.Message("should not be 1"),
+				errors.ExitCode(7),
+			)},
+		},
+		IDs: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Aborts with a custom error code and message",
+		InputType:        types.Any{},
+		InputDescription: "any value",
+		Name:             "fatal",
+		Params: []*params.Param{
+			params.SimpleParam("code", "an error code in UpperCamelCase, e.g., \"BadConfig\"", types.Str{}),
+			params.SimpleParam("message", "a message describing the problem", types.Str{}),
+		},
+		OutputType:        types.Void{},
+		OutputDescription: "does not return",
+		Notes:             "The code can be inspected by catch, e.g., to handle different problems differently.",
+		Kernel:            fatalKernel,
+		Examples: []shapes.Example{
+			{`"x" if =="x" then fatal("BadInput", "x is not allowed") else true ok`, `Bool`, ``, errors.ValueError(
+				errors.UserCode("BadInput"),
+				errors.GotValue(states.StrValue("x")),
+				errors.Message("x is not allowed"),
+			)},
+			{`"x" if =="x" then fatal("BadInput", "x is not allowed") else true ok catch(@code)`, `Bool|Str`, `"BadInput"`, nil},
+			{`"x" if =="x" then fatal("bad input", "x is not allowed") else true ok`, `Bool`, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("bad input")),
+			)},
+		},
+		IDs: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Aborts with a custom error code, message, and hint",
+		InputType:        types.Any{},
+		InputDescription: "any value",
+		Name:             "fatal",
+		Params: []*params.Param{
+			params.SimpleParam("code", "an error code in UpperCamelCase, e.g., \"BadConfig\"", types.Str{}),
+			params.SimpleParam("message", "a message describing the problem", types.Str{}),
+			params.SimpleParam("hint", "a suggestion on how to fix the problem", types.Str{}),
+		},
+		OutputType:        types.Void{},
+		OutputDescription: "does not return",
+		Notes:             "The code can be inspected by catch, e.g., to handle different problems differently.",
+		Kernel:            fatalKernel,
+		Examples: []shapes.Example{
+			{`"{{}}" parseJSON if is {port: _} then true else fatal("BadConfig", "port is missing", "Add a port property.") ok`, `Bool`, ``, errors.ValueError(
+				errors.UserCode("BadConfig"),
+				errors.Message("port is missing"),
+				errors.Hint("Add a port property."),
+			)},
+		},
+		IDs: nil,
+	},
 	shapes.Funcer{
 		Summary: "Aborts with an error message if input is null",
 		InputType: types.NewUnion(
@@ -80,3 +392,87 @@ var ControlFuncers = []shapes.Funcer{
 		IDs: nil,
 	},
 }
+
+// errorObj converts an error to the object that catch passes to its handler.
+func errorObj(e *errors.E) states.ObjValue {
+	code := e.CodeName()
+	message := ""
+	if e.Code != nil {
+		message = e.Code.DefaultMessage()
+	}
+	if e.Message != nil {
+		message = *e.Message
+	}
+	var value states.Value = states.NullValue{}
+	if e.GotValue != nil {
+		value = e.GotValue
+	}
+	return states.ObjFromValMap(map[string]states.Value{
+		"kind":    states.StrValue(e.Kind.String()),
+		"code":    states.StrValue(code),
+		"message": states.StrValue(message),
+		"value":   value,
+	})
+}
+
+var codeRegexp = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// exitCodeArg evaluates an exit code argument of fatal and checks that it is
+// valid.
+func exitCodeArg(inputState states.State, arg states.Action, pos lexer.Position) (int, error) {
+	exitCode, err := arg(inputState.Clear(), nil).Thunk.EvalNum()
+	if err != nil {
+		return 0, err
+	}
+	if exitCode != math.Trunc(exitCode) || exitCode < 1 || exitCode > 255 {
+		return 0, errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+			errors.Pos(pos),
+			errors.GotValue(states.NumValue(exitCode)),
+			errors.Message("The exit code must be an integer between 1 and 255."),
+		)
+	}
+	return int(exitCode), nil
+}
+
+func fatalKernel(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+	inputValue, err := inputState.Thunk.Eval()
+	if err != nil {
+		return states.ThunkFromError(err)
+	}
+	code, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
+	if err != nil {
+		return states.ThunkFromError(err)
+	}
+	if !codeRegexp.MatchString(code) {
+		return states.ThunkFromError(errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+			errors.Pos(pos),
+			errors.GotValue(states.StrValue(code)),
+			errors.Message("Error codes must be in UpperCamelCase."),
+		))
+	}
+	message, err := args[1](inputState.Clear(), nil).Thunk.EvalStr()
+	if err != nil {
+		return states.ThunkFromError(err)
+	}
+	if len(args) > 2 {
+		hint, err := args[2](inputState.Clear(), nil).Thunk.EvalStr()
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		return states.ThunkFromError(errors.ValueError(
+			errors.UserCode(code),
+			errors.Pos(pos),
+			errors.GotValue(inputValue),
+			errors.Message(message),
+			errors.Hint(hint),
+		))
+	}
+	return states.ThunkFromError(errors.ValueError(
+		errors.UserCode(code),
+		errors.Pos(pos),
+		errors.GotValue(inputValue),
+		errors.Message(message),
+	))
+}
@@ -2,20 +2,117 @@ package builtin
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/BurntSushi/toml"
 	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/params"
 	"github.com/texttheater/bach/shapes"
 	"github.com/texttheater/bach/states"
 	"github.com/texttheater/bach/types"
+	"gopkg.in/yaml.v2"
 )
 
+// argsID identifies the variable holding the command-line arguments that
+// follow the program.
+type argsID struct{}
+
+// BindArgs returns the given state with the command-line arguments that
+// follow the program bound, so that the args funcer returns them.
+func BindArgs(state states.State, args []string) states.State {
+	state.Stack = state.Stack.Push(states.Variable{
+		ID:     argsID{},
+		Action: states.SimpleAction(states.ThunkFromValue(strArr(args))),
+	})
+	return state
+}
+
+// stdoutID and stderrID identify the variables holding the Writers that
+// replace STDOUT and STDERR.
+type stdoutID struct{}
+
+type stderrID struct{}
+
+// BindOutput returns the given state with the given Writers bound in place of
+// STDOUT and STDERR, so that the out, err, stdout and stderr funcers use them.
+// Programs embedding Bach can use this to capture output.
+func BindOutput(state states.State, stdout io.Writer, stderr io.Writer) states.State {
+	state.Stack = state.Stack.Push(states.Variable{
+		ID:     stdoutID{},
+		Action: states.SimpleAction(states.ThunkFromValue(states.WriterValue{Writer: stdout})),
+	})
+	state.Stack = state.Stack.Push(states.Variable{
+		ID:     stderrID{},
+		Action: states.SimpleAction(states.ThunkFromValue(states.WriterValue{Writer: stderr})),
+	})
+	return state
+}
+
+// boundWriter returns the Writer bound with the given ID in the given state,
+// or def if there is none.
+func boundWriter(state states.State, id any, def io.Writer) io.Writer {
+	for stack := state.Stack; stack != nil; stack = stack.Tail {
+		if stack.Head.ID == id {
+			w, err := stack.Head.Action(states.InitialState, nil).Thunk.EvalWriter()
+			if err == nil {
+				return w
+			}
+		}
+	}
+	return def
+}
+
 var IOFuncers = []shapes.Funcer{
+	shapes.Funcer{
+		Summary:          "Appends to a file.",
+		InputType:        types.NewUnion(types.Str{}, types.NewArr(types.Str{})),
+		InputDescription: "a string, or an array of lines",
+		Name:             "appendFile",
+		Params: []*params.Param{
+			params.SimpleParam("path", "path of the file to append to", types.Str{}),
+		},
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Like writeFile, but if the file already exists, the input is added at the end rather than replacing its contents.",
+		Kernel:            writeFileKernel(os.O_WRONLY | os.O_CREATE | os.O_APPEND),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"abc" appendFile("out.txt") null`, `Null`, `null`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Returns the command-line arguments.",
+		InputType:         types.Any{},
+		InputDescription:  "any value (is ignored)",
+		Name:              "args",
+		Params:            nil,
+		OutputType:        types.NewArr(types.Str{}),
+		OutputDescription: "the arguments given on the command line after the program",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			for stack := inputState.Stack; stack != nil; stack = stack.Tail {
+				if stack.Head.ID == (argsID{}) {
+					return stack.Head.Action(states.InitialState, nil).Thunk
+				}
+			}
+			return states.ThunkFromValue(strArr(nil))
+		},
+		IDs: &states.IDStack{
+			Head: argsID{},
+		},
+		Examples: []shapes.Example{
+			{`args`, `Arr<Str...>`, `[]`, nil},
+		},
+	},
 	shapes.Funcer{
 		Summary:           "Groups lines into blocks separated by empty lines.",
 		InputType:         types.NewArr(types.Str{}),
@@ -80,47 +177,314 @@ var IOFuncers = []shapes.Funcer{
 		},
 	},
 	shapes.SimpleFuncer(
-		"Writes to STDERR.",
-		types.NewVar("A", types.Any{}),
-		"any value",
-		"err",
+		"Creates an in-memory Writer.",
+		types.Any{},
+		"any value (is ignored)",
+		"buffer",
 		nil,
-		types.Null{},
-		"null",
-		"Writes a string representation of the value to STDERR, followed by a line break.",
+		types.Writer{},
+		"a Writer that stores everything written to it in memory",
+		"Use contents to get what has been written.",
 		func(inputThunk *states.Thunk, argThunks []*states.Thunk) *states.Thunk {
-			str, err := inputThunk.EvalStr()
-			if err != nil {
-				return states.ThunkFromError(err)
-			}
-			fmt.Fprintln(os.Stderr, str)
-			return inputThunk
+			return states.ThunkFromValue(states.WriterValue{
+				Writer: &bytes.Buffer{},
+			})
+		},
+		[]shapes.Example{
+			{`buffer =b "abc" write(b) =_ b contents`, `Str`, `"abc\n"`, nil},
 		},
-		nil,
 	),
+	shapes.Funcer{
+		Summary:           "Closes a Writer.",
+		InputType:         types.Writer{},
+		InputDescription:  "a Writer",
+		Name:              "close",
+		Params:            nil,
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Closes a file opened with fileWriter or fileAppender, after which it can no longer be written to. Other Writers, such as buffers and the standard streams, are left as they are.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				w, err := inputState.Thunk.EvalWriter()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				if file, ok := w.(openedFile); ok {
+					if err := file.Close(); err != nil {
+						return states.ThunkFromError(fileError(pos, file.Name(), err))
+					}
+				}
+				return states.ThunkFromValue(states.NullValue{})
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`buffer =b "abc" write(b) =_ b close =_ b contents`, `Str`, `"abc\n"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Returns what has been written to an in-memory Writer.",
+		InputType:         types.Writer{},
+		InputDescription:  "a Writer created with buffer",
+		Name:              "contents",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "everything written to the Writer so far",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				val, err := inputState.Thunk.Eval()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				buffer, ok := val.(states.WriterValue).Writer.(*bytes.Buffer)
+				if !ok {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Code(errors.UnexpectedValue),
+						errors.Pos(pos),
+						errors.GotValue(val),
+						errors.Message("Only Writers created with buffer have contents."),
+					))
+				}
+				return states.ThunkFromValue(states.StrValue(buffer.String()))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`buffer contents`, `Str`, `""`, nil},
+			{`stdout contents`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Reads CSV records from a stream.",
+		InputType:         types.Reader{},
+		InputDescription:  "a Reader",
+		Name:              "csv",
+		Params:            nil,
+		OutputType:        types.NewArr(types.NewArr(types.Str{})),
+		OutputDescription: "array of records, each an array of fields",
+		Notes:             "The format is that of [RFC 4180](https://www.rfc-editor.org/rfc/rfc4180), with fields separated by commas and optionally quoted with double quotes. Records do not need to have the same number of fields. Use withHeader to turn the records into objects.",
+		Kernel:            CSV,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"a,b,c\n1,\"2,3\",4" reader csv`, `Arr<Arr<Str...>...>`, `[["a", "b", "c"], ["1", "2,3", "4"]]`, nil},
+			{`"a,b\n1,\"2\n" reader csv`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Message("parse error on line 2, column 6: extraneous or missing \" in quoted-field"),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Reads CSV records with a custom delimiter from a stream.",
+		InputType:        types.Reader{},
+		InputDescription: "a Reader",
+		Name:             "csv",
+		Params: []*params.Param{
+			params.SimpleParam("delimiter", "the character separating fields", types.Str{}),
+		},
+		OutputType:        types.NewArr(types.NewArr(types.Str{})),
+		OutputDescription: "array of records, each an array of fields",
+		Notes:             "Like csv without arguments, but with fields separated by the given delimiter.",
+		Kernel:            CSV,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"a;b\n1;\"2;3\"" reader csv(";")`, `Arr<Arr<Str...>...>`, `[["a", "b"], ["1", "2;3"]]`, nil},
+			{`"a;b" reader csv(";;")`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue(";;")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Reads CSV records with a custom delimiter and quote character from a stream.",
+		InputType:        types.Reader{},
+		InputDescription: "a Reader",
+		Name:             "csv",
+		Params: []*params.Param{
+			params.SimpleParam("delimiter", "the character separating fields", types.Str{}),
+			params.SimpleParam("quote", "the character for quoting fields", types.Str{}),
+		},
+		OutputType:        types.NewArr(types.NewArr(types.Str{})),
+		OutputDescription: "array of records, each an array of fields",
+		Notes:             "Like csv without arguments, but with fields separated by the given delimiter and quoted with the given quote character.",
+		Kernel:            CSV,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"a|'b|c'|\"d\"" reader csv("|", "'")`, `Arr<Arr<Str...>...>`, `[["a", "b|c", "\"d\""]]`, nil},
+			{`"a|'\xff|'" reader csv("|", "'")`, `Arr<Arr<Str...>...>`, `[["a", "\xff|"]]`, nil},
+			{`"a\"b" reader csv("\"", "'")`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("\"")),
+				errors.Message("invalid delimiter"),
+			)},
+		},
+	},
 	shapes.SimpleFuncer(
-		"Writes to STDERR with a custom line end.",
-		types.NewVar("A", types.Any{}),
-		"any value",
-		"err",
+		"Looks up an environment variable.",
+		types.Any{},
+		"any value (is ignored)",
+		"env",
 		[]*params.Param{
-			params.SimpleParam("end", "the line end to use", types.Str{}),
+			params.SimpleParam("name", "name of the environment variable", types.Str{}),
 		},
-		types.Null{},
-		"null",
-		"Writes a string representation of the value to STDERR, followed by the specified line end.",
+		types.NewUnion(types.Null{}, types.Str{}),
+		"the value of the environment variable, or null if it is not set",
+		"",
 		func(inputThunk *states.Thunk, argThunks []*states.Thunk) *states.Thunk {
-			str, err := inputThunk.EvalStr()
+			name, err := argThunks[0].EvalStr()
 			if err != nil {
 				return states.ThunkFromError(err)
 			}
-			end, err := argThunks[0].EvalStr()
-			fmt.Fprint(os.Stderr, str)
-			fmt.Fprint(os.Stderr, end)
-			return inputThunk
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return states.ThunkFromValue(states.NullValue{})
+			}
+			return states.ThunkFromValue(states.StrValue(value))
+		},
+		[]shapes.Example{
+			{`env("BACH_NO_SUCH_VARIABLE")`, `Null|Str`, `null`, nil},
 		},
+	),
+	shapes.SimpleFuncer(
+		"Returns all environment variables.",
+		types.Any{},
+		"any value (is ignored)",
+		"envs",
 		nil,
+		types.Obj{
+			Props: map[string]types.Type{},
+			Rest:  types.Str{},
+		},
+		"an object mapping the names of all environment variables to their values",
+		"",
+		func(inputThunk *states.Thunk, argThunks []*states.Thunk) *states.Thunk {
+			obj := make(map[string]states.Value)
+			for _, variable := range os.Environ() {
+				name, value, _ := strings.Cut(variable, "=")
+				obj[name] = states.StrValue(value)
+			}
+			return states.ThunkFromValue(states.ObjFromValMap(obj))
+		},
+		[]shapes.Example{
+			{`envs type`, `Str`, `"Obj<Str>"`, nil},
+		},
 	),
+	shapes.Funcer{
+		Summary:           "Writes to STDERR.",
+		InputType:         types.NewVar("A", types.Any{}),
+		InputDescription:  "any value",
+		Name:              "err",
+		Params:            nil,
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to STDERR, followed by a line break.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stderrID{}, os.Stderr)
+			str, err := inputState.Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			fmt.Fprintln(w, str)
+			return inputState.Thunk
+		},
+		IDs: &states.IDStack{
+			Head: stderrID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Writes to STDERR with a custom line end.",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "any value",
+		Name:             "err",
+		Params: []*params.Param{
+			params.SimpleParam("end", "the line end to use", types.Str{}),
+		},
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to STDERR, followed by the specified line end.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stderrID{}, os.Stderr)
+			str, err := inputState.Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			end, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
+			fmt.Fprint(w, str)
+			fmt.Fprint(w, end)
+			return inputState.Thunk
+		},
+		IDs: &states.IDStack{
+			Head: stderrID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:           "Opens a file for appending.",
+		InputType:         types.Str{},
+		InputDescription:  "path of a file",
+		Name:              "fileAppender",
+		Params:            nil,
+		OutputType:        types.Writer{},
+		OutputDescription: "a Writer that appends to the file",
+		Notes:             "The file is created if it does not exist. Since Bach evaluates lazily, it is only opened once the Writer is used; in the example, only the type is inspected, so the file is not opened. Once opened, the file stays open until it is closed with close or the program ends.",
+		Kernel:            fileWriterKernel(os.O_WRONLY | os.O_CREATE | os.O_APPEND),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"out.txt" fileAppender type`, `Str`, `"Writer"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Opens a file for writing.",
+		InputType:         types.Str{},
+		InputDescription:  "path of a file",
+		Name:              "fileWriter",
+		Params:            nil,
+		OutputType:        types.Writer{},
+		OutputDescription: "a Writer that writes to the file",
+		Notes:             "The file is created if it does not exist. If it exists, its contents are replaced. The file stays open until it is closed with close or the program ends.",
+		Kernel:            fileWriterKernel(os.O_WRONLY | os.O_CREATE | os.O_TRUNC),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`"abc" write("no/such/dir/file.txt" fileWriter)`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("no/such/dir/file.txt")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Finds files matching a pattern.",
+		InputType:         types.Str{},
+		InputDescription:  "a glob pattern",
+		Name:              "glob",
+		Params:            nil,
+		OutputType:        types.NewArr(types.Str{}),
+		OutputDescription: "the paths of all files matching the pattern, in lexical order",
+		Notes:             "The pattern syntax is that of Go's [filepath.Match](https://pkg.go.dev/path/filepath#Match): `*` matches any sequence of characters except the path separator, `?` matches any single character except the path separator, and `[...]` matches a character class.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				pattern, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				paths, err := filepath.Glob(pattern)
+				if err != nil {
+					return states.ThunkFromError(fileError(pos, pattern, err))
+				}
+				return states.ThunkFromValue(strArr(paths))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"no/such/dir/*.txt" glob`, `Arr<Str...>`, `[]`, nil},
+			{`"[" glob`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("[")),
+			)},
+		},
+	},
 	shapes.SimpleFuncer(
 		"Reads from STDIN.",
 		types.Any{},
@@ -144,31 +508,9 @@ var IOFuncers = []shapes.Funcer{
 		OutputType:        types.AnyArr,
 		OutputDescription: "array of data structures as they appear in the stream",
 		Notes:             "",
-		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
-			reader, err := inputState.Thunk.EvalReader()
-			if err != nil {
-				return states.ThunkFromError(err)
-			}
-			dec := json.NewDecoder(reader)
-			output := func() (*states.Thunk, bool, error) {
-				if !dec.More() {
-					return nil, false, nil
-				}
-				var o any
-				err := dec.Decode(&o)
-				if err != nil {
-					return nil, false, errors.ValueError(
-						errors.Pos(pos),
-						errors.Code(errors.UnexpectedValue),
-						errors.Message(err.Error()),
-					)
-				}
-				return thunkFromData(o, pos), true, nil
-			}
-			return states.ThunkFromIter(output)
-		},
-		IDs:      nil,
-		Examples: nil,
+		Kernel:            JSON,
+		IDs:               nil,
+		Examples:          nil,
 	},
 	shapes.Funcer{
 		Summary:           "Reads a stream line-by-line",
@@ -185,17 +527,52 @@ var IOFuncers = []shapes.Funcer{
 			{`"abc\nde\n\nf" reader lines`, `Arr<Str...>`, `["abc", "de", "", "f"]`, nil},
 		},
 	},
-	shapes.SimpleFuncer(
-		"Writes to STDOUT.",
-		types.NewVar("A", types.Any{}),
-		"any value",
-		"out",
-		nil,
-		types.Null{},
-		"null",
-		"Writes a string representation of the value to STDERR, followed by a line break.",
-		func(inputThunk *states.Thunk, argThunks []*states.Thunk) *states.Thunk {
-			val, err := inputThunk.Eval()
+	shapes.Funcer{
+		Summary:           "Lists the entries of a directory.",
+		InputType:         types.Str{},
+		InputDescription:  "path of a directory",
+		Name:              "listDir",
+		Params:            nil,
+		OutputType:        types.NewArr(types.Str{}),
+		OutputDescription: "the paths of all entries in the directory, in lexical order",
+		Notes:             "The paths consist of the path of the directory joined with the names of the entries.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				dir, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					return states.ThunkFromError(fileError(pos, dir, err))
+				}
+				paths := make([]string, len(entries))
+				for i, entry := range entries {
+					paths[i] = filepath.Join(dir, entry.Name())
+				}
+				return states.ThunkFromValue(strArr(paths))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"no/such/dir" listDir`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("no/such/dir")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Writes to STDOUT.",
+		InputType:         types.NewVar("A", types.Any{}),
+		InputDescription:  "any value",
+		Name:              "out",
+		Params:            nil,
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to STDERR, followed by a line break.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stdoutID{}, os.Stdout)
+			val, err := inputState.Thunk.Eval()
 			if err != nil {
 				return states.ThunkFromError(err)
 			}
@@ -203,37 +580,106 @@ var IOFuncers = []shapes.Funcer{
 			if err != nil {
 				return states.ThunkFromError(err)
 			}
-			fmt.Println(str)
+			fmt.Fprintln(w, str)
 			return states.ThunkFromValue(states.NullValue{})
 		},
-		nil,
-	),
-	shapes.SimpleFuncer(
-		"Writes to STDOUT with a custom line end.",
-		types.NewVar("A", types.Any{}),
-		"any value",
-		"out",
-		[]*params.Param{
+		IDs: &states.IDStack{
+			Head: stdoutID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:          "Writes to STDOUT with a custom line end.",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "any value",
+		Name:             "out",
+		Params: []*params.Param{
 			params.SimpleParam("end", "", types.Str{}),
 		},
-		types.Null{},
-		"null",
-		"Writes a string representation of the value to STDOUT, followed by the specified line end.",
-		func(inputThunk *states.Thunk, argThunks []*states.Thunk) *states.Thunk {
-			str, err := inputThunk.EvalStr()
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to STDOUT, followed by the specified line end.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stdoutID{}, os.Stdout)
+			str, err := inputState.Thunk.EvalStr()
 			if err != nil {
 				return states.ThunkFromError(err)
 			}
-			end, err := argThunks[0].EvalStr()
+			end, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
 			if err != nil {
 				return states.ThunkFromError(err)
 			}
-			fmt.Print(str)
-			fmt.Print(end)
+			fmt.Fprint(w, str)
+			fmt.Fprint(w, end)
 			return states.ThunkFromValue(states.NullValue{})
 		},
-		nil,
-	),
+		IDs: &states.IDStack{
+			Head: stdoutID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:           "Opens a file for reading.",
+		InputType:         types.Str{},
+		InputDescription:  "path of a file",
+		Name:              "readFile",
+		Params:            nil,
+		OutputType:        types.Reader{},
+		OutputDescription: "a Reader from which the contents of the file can be read",
+		Notes:             "The file is closed when it has been read to the end.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				path, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				file, err := os.Open(path)
+				if err != nil {
+					return states.ThunkFromError(fileError(pos, path, err))
+				}
+				return states.ThunkFromValue(states.ReaderValue{
+					Reader: closingReader{file},
+				})
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"no/such/file.txt" readFile lines`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("no/such/file.txt")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Reads a whole file into a string.",
+		InputType:         types.Str{},
+		InputDescription:  "path of a file",
+		Name:              "readText",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "the contents of the file",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				path, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return states.ThunkFromError(fileError(pos, path, err))
+				}
+				return states.ThunkFromValue(states.StrValue(content))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"no/such/file.txt" readText`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("no/such/file.txt")),
+			)},
+		},
+	},
 	shapes.SimpleFuncer(
 		"Creates a Reader from a Str.",
 		types.Str{},
@@ -254,6 +700,299 @@ var IOFuncers = []shapes.Funcer{
 		},
 		nil,
 	),
+	shapes.Funcer{
+		Summary:           "Returns a Writer for STDERR.",
+		InputType:         types.Any{},
+		InputDescription:  "any value (is ignored)",
+		Name:              "stderr",
+		Params:            nil,
+		OutputType:        types.Writer{},
+		OutputDescription: "a Writer representing STDERR",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stderrID{}, os.Stderr)
+			return states.ThunkFromValue(states.WriterValue{Writer: w})
+		},
+		IDs: &states.IDStack{
+			Head: stderrID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:           "Returns a Writer for STDOUT.",
+		InputType:         types.Any{},
+		InputDescription:  "any value (is ignored)",
+		Name:              "stdout",
+		Params:            nil,
+		OutputType:        types.Writer{},
+		OutputDescription: "a Writer representing STDOUT",
+		Notes:             "",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			w := boundWriter(inputState, stdoutID{}, os.Stdout)
+			return states.ThunkFromValue(states.WriterValue{Writer: w})
+		},
+		IDs: &states.IDStack{
+			Head: stdoutID{},
+		},
+		Examples: nil,
+	},
+	shapes.Funcer{
+		Summary:           "Reads a TOML document from a stream.",
+		InputType:         types.Reader{},
+		InputDescription:  "a Reader",
+		Name:              "toml",
+		Params:            nil,
+		OutputType:        types.AnyObj,
+		OutputDescription: "the table represented by the document",
+		Notes:             "Integers and floats become numbers. Dates and times become strings in RFC 3339 format, or the corresponding partial format for local dates and times.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				reader, err := inputState.Thunk.EvalReader()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				var data map[string]any
+				_, err = toml.NewDecoder(reader).Decode(&data)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Pos(pos),
+						errors.Code(errors.UnexpectedValue),
+						errors.Message(err.Error()),
+					))
+				}
+				return thunkFromForeignData(data, pos)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"[server]\nhost = \"localhost\"\nports = [8000, 8001]\n" reader toml`, `Obj<Any>`, `{server: {host: "localhost", ports: [8000, 8001]}}`, nil},
+			{`"released = 1979-05-27\n" reader toml`, `Obj<Any>`, `{released: "1979-05-27"}`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Reads tab-separated values from a stream.",
+		InputType:         types.Reader{},
+		InputDescription:  "a Reader",
+		Name:              "tsv",
+		Params:            nil,
+		OutputType:        types.NewArr(types.NewArr(types.Str{})),
+		OutputDescription: "array of records, each an array of fields",
+		Notes:             "Each line is a record, and fields are separated by tab characters. Lines can end with LF or CRLF. There is no quoting, so fields cannot contain tabs or line breaks. Use withHeader to turn the records into objects.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				reader, err := inputState.Thunk.EvalReader()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				// unlike bufio.Scanner, bufio.Reader does not limit
+				// the line length
+				r := bufio.NewReader(reader)
+				lineNum := 0
+				iter := func() (*states.Thunk, bool, error) {
+					line, err := r.ReadString('\n')
+					if err == io.EOF && line == "" {
+						return nil, false, nil
+					}
+					lineNum++
+					if err != nil && err != io.EOF {
+						return nil, false, errors.ValueError(
+							errors.Code(errors.UnexpectedValue),
+							errors.Pos(pos),
+							errors.Message(fmt.Sprintf("line %d: %s", lineNum, err)),
+						)
+					}
+					line = strings.TrimSuffix(line, "\n")
+					line = strings.TrimSuffix(line, "\r")
+					fields := strings.Split(line, "\t")
+					return states.ThunkFromValue(strArr(fields)), true, nil
+				}
+				return states.ThunkFromIter(iter)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"a\tb\n1\t\"2\"" reader tsv`, `Arr<Arr<Str...>...>`, `[["a", "b"], ["1", "\"2\""]]`, nil},
+			{`"a\tb\r\n1\t2\r\n" reader tsv`, `Arr<Arr<Str...>...>`, `[["a", "b"], ["1", "2"]]`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Turns records into objects, using the first record as header.",
+		InputType:         types.NewArr(types.NewArr(types.Str{})),
+		InputDescription:  "an array of records, e.g., as read by csv or tsv",
+		Name:              "withHeader",
+		Params:            nil,
+		OutputType:        types.NewArr(types.Obj{Props: map[string]types.Type{}, Rest: types.Str{}}),
+		OutputDescription: "an array with one object per record except the first, mapping the fields of the first record to the corresponding fields of the record",
+		Notes:             "All records must have as many fields as the header, and the fields of the header must be distinct.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				input := states.IterFromThunk(inputState.Thunk)
+				var header []string
+				n := 0
+				iter := func() (*states.Thunk, bool, error) {
+					for {
+						thk, ok, err := input()
+						if !ok || err != nil {
+							return nil, false, err
+						}
+						n++
+						val, err := thk.Eval()
+						if err != nil {
+							return nil, false, err
+						}
+						record, err := strSliceFromThunk(thk)
+						if err != nil {
+							return nil, false, err
+						}
+						if header == nil {
+							seen := make(map[string]bool, len(record))
+							for _, key := range record {
+								if seen[key] {
+									return nil, false, errors.ValueError(
+										errors.Code(errors.UnexpectedValue),
+										errors.Pos(pos),
+										errors.GotValue(val),
+										errors.Message(fmt.Sprintf("the header has more than one column named %q", key)),
+									)
+								}
+								seen[key] = true
+							}
+							header = record
+							continue
+						}
+						if len(record) != len(header) {
+							return nil, false, errors.ValueError(
+								errors.Code(errors.UnexpectedValue),
+								errors.Pos(pos),
+								errors.GotValue(val),
+								errors.Message(fmt.Sprintf("record %d has %d fields, but the header has %d", n, len(record), len(header))),
+							)
+						}
+						obj := make(map[string]states.Value, len(header))
+						for i, key := range header {
+							obj[key] = states.StrValue(record[i])
+						}
+						return states.ThunkFromValue(states.ObjFromValMap(obj)), true, nil
+					}
+				}
+				return states.ThunkFromIter(iter)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"name,age\nAda,36\nAlan,41" reader csv withHeader`, `Arr<Obj<Str>...>`, `[{name: "Ada", age: "36"}, {name: "Alan", age: "41"}]`, nil},
+			{`"a\tb" reader tsv withHeader`, `Arr<Obj<Str>...>`, `[]`, nil},
+			{`[["a", "b"], ["1"]] withHeader`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.ArrFromSlice([]states.Value{states.StrValue("1")})),
+				errors.Message("record 2 has 1 fields, but the header has 2"),
+			)},
+			{`[["a", "a"], ["1", "2"]] withHeader`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.ArrFromSlice([]states.Value{states.StrValue("a"), states.StrValue("a")})),
+				errors.Message("the header has more than one column named \"a\""),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Writes to a Writer.",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "any value",
+		Name:             "write",
+		Params: []*params.Param{
+			params.SimpleParam("w", "the Writer to write to", types.Writer{}),
+		},
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to the Writer, followed by a line break.",
+		Kernel:            writeKernel(false),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`buffer =b "a" write(b) =_ ["b", 1] write(b) =_ b contents`, `Str`, `"a\n[\"b\", 1]\n"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Writes to a Writer with a custom line end.",
+		InputType:        types.NewVar("A", types.Any{}),
+		InputDescription: "any value",
+		Name:             "write",
+		Params: []*params.Param{
+			params.SimpleParam("w", "the Writer to write to", types.Writer{}),
+			params.SimpleParam("end", "the line end to use", types.Str{}),
+		},
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "Writes a string representation of the value to the Writer, followed by the specified line end.",
+		Kernel:            writeKernel(true),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`buffer =b 1 write(b, ", ") =_ 2 write(b, "") =_ b contents`, `Str`, `"1, 2"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Writes to a file.",
+		InputType:        types.NewUnion(types.Str{}, types.NewArr(types.Str{})),
+		InputDescription: "a string, or an array of lines",
+		Name:             "writeFile",
+		Params: []*params.Param{
+			params.SimpleParam("path", "path of the file to write to", types.Str{}),
+		},
+		OutputType:        types.Null{},
+		OutputDescription: "null",
+		Notes:             "A string is written as is. The elements of an array are written one by one as they are computed, each followed by a line break. If the file already exists, its contents are replaced. Since Bach evaluates lazily, the file is only written if the output is used, e.g., by assigning it to a variable as in `writeFile(\"out.txt\") =_`. In the example, the output is discarded, so nothing is written.",
+		Kernel:            writeFileKernel(os.O_WRONLY | os.O_CREATE | os.O_TRUNC),
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`["a", "b"] writeFile("out.txt") null`, `Null`, `null`, nil},
+			{`"abc" writeFile("no/such/dir/file.txt")`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("no/such/dir/file.txt")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Reads YAML documents from a stream.",
+		InputType:         types.Reader{},
+		InputDescription:  "a Reader",
+		Name:              "yaml",
+		Params:            nil,
+		OutputType:        types.AnyArr,
+		OutputDescription: "array of the documents in the stream",
+		Notes:             "Mapping keys that are not strings, such as numbers and booleans, are converted to strings. Mappings with keys that are themselves mappings or sequences cause an error.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				reader, err := inputState.Thunk.EvalReader()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				dec := yaml.NewDecoder(reader)
+				output := func() (*states.Thunk, bool, error) {
+					var o any
+					err := dec.Decode(&o)
+					if err == io.EOF {
+						return nil, false, nil
+					}
+					if err != nil {
+						return nil, false, errors.ValueError(
+							errors.Pos(pos),
+							errors.Code(errors.UnexpectedValue),
+							errors.Message(err.Error()),
+						)
+					}
+					return thunkFromForeignData(o, pos), true, nil
+				}
+				return states.ThunkFromIter(output)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"a: 1\nb: [x, z]\n---\n- true\n- null\n" reader yaml`, `Arr<Any...>`, `[{a: 1, b: ["x", "z"]}, [true, null]]`, nil},
+			{`"1: one\ntrue: two\n" reader yaml`, `Arr<Any...>`, `[{"1": "one", "true": "two"}]`, nil},
+			{`"? [a]\n: b\n" reader yaml`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+			)},
+		},
+	},
 }
 
 func Lines(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
@@ -273,3 +1012,352 @@ func Lines(inputState states.State, args []states.Action, bindings map[string]ty
 		return states.ThunkFromIter(iter)
 	})
 }
+
+func JSON(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+	return states.ThunkFromFunc(func() *states.Thunk {
+		reader, err := inputState.Thunk.EvalReader()
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		dec := json.NewDecoder(reader)
+		output := func() (*states.Thunk, bool, error) {
+			if !dec.More() {
+				return nil, false, nil
+			}
+			var o any
+			err := dec.Decode(&o)
+			if err != nil {
+				return nil, false, errors.ValueError(
+					errors.Pos(pos),
+					errors.Code(errors.UnexpectedValue),
+					errors.Message(err.Error()),
+				)
+			}
+			return ThunkFromData(o, pos), true, nil
+		}
+		return states.ThunkFromIter(output)
+	})
+}
+
+// CSV is the kernel of the csv funcers. It is also used by the CLI for reading
+// STDIN in CSV mode.
+func CSV(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+	return states.ThunkFromFunc(func() *states.Thunk {
+		comma, quote, err := csvDialect(inputState, args, pos)
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		reader, err := inputState.Thunk.EvalReader()
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		if quote != '"' {
+			reader = &quoteSwapper{bufio.NewReader(reader), quote}
+		}
+		r := csv.NewReader(reader)
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		iter := func() (*states.Thunk, bool, error) {
+			record, err := r.Read()
+			if err == io.EOF {
+				return nil, false, nil
+			}
+			if err != nil {
+				return nil, false, errors.ValueError(
+					errors.Pos(pos),
+					errors.Code(errors.UnexpectedValue),
+					errors.Message(err.Error()),
+				)
+			}
+			if quote != '"' {
+				for i, field := range record {
+					record[i] = swapQuotes(field, quote)
+				}
+			}
+			return states.ThunkFromValue(strArr(record)), true, nil
+		}
+		return states.ThunkFromIter(iter)
+	})
+}
+
+// csvDialect evaluates the optional delimiter and quote arguments of the csv
+// and toCSV funcers, defaulting to comma and double quote.
+func csvDialect(inputState states.State, args []states.Action, pos lexer.Position) (comma rune, quote rune, err error) {
+	comma, quote = ',', '"'
+	if len(args) > 0 {
+		comma, err = runeArg(inputState, args[0], pos)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(args) > 1 {
+		quote, err = runeArg(inputState, args[1], pos)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	// with a custom quote character, double quotes are swapped with it, so
+	// they cannot be the delimiter either
+	if comma == '\r' || comma == '\n' || comma == quote || comma == '"' {
+		return 0, 0, errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+			errors.Pos(pos),
+			errors.GotValue(states.StrValue(string(comma))),
+			errors.Message("invalid delimiter"),
+		)
+	}
+	if quote == '\r' || quote == '\n' {
+		return 0, 0, errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+			errors.Pos(pos),
+			errors.GotValue(states.StrValue(string(quote))),
+			errors.Message("invalid quote character"),
+		)
+	}
+	return comma, quote, nil
+}
+
+// runeArg evaluates an argument that must be a string consisting of exactly
+// one character.
+func runeArg(inputState states.State, arg states.Action, pos lexer.Position) (rune, error) {
+	str, err := arg(inputState.Clear(), nil).Thunk.EvalStr()
+	if err != nil {
+		return 0, err
+	}
+	runes := []rune(str)
+	if len(runes) != 1 {
+		return 0, errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+			errors.Pos(pos),
+			errors.GotValue(states.StrValue(str)),
+			errors.Message("expected a single character"),
+		)
+	}
+	return runes[0], nil
+}
+
+// quoteSwapper reads from a stream, exchanging a custom quote character with
+// the double quote so that encoding/csv can parse the stream.
+type quoteSwapper struct {
+	reader *bufio.Reader
+	quote  rune
+}
+
+func (s *quoteSwapper) Read(p []byte) (int, error) {
+	n := 0
+	for n+utf8.UTFMax <= len(p) {
+		r, size, err := s.reader.ReadRune()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		if r == utf8.RuneError && size == 1 {
+			// pass invalid UTF-8 through unchanged
+			s.reader.UnreadRune()
+			p[n], _ = s.reader.ReadByte()
+			n++
+		} else {
+			n += utf8.EncodeRune(p[n:], swapQuote(r, s.quote))
+		}
+		if s.reader.Buffered() == 0 {
+			break
+		}
+	}
+	return n, nil
+}
+
+func swapQuote(r rune, quote rune) rune {
+	switch r {
+	case quote:
+		return '"'
+	case '"':
+		return quote
+	default:
+		return r
+	}
+}
+
+// swapQuotes exchanges a custom quote character with the double quote in str.
+// Other bytes, including invalid UTF-8, are left unchanged.
+func swapQuotes(str string, quote rune) string {
+	return strings.NewReplacer(string(quote), `"`, `"`, string(quote)).Replace(str)
+}
+
+// strSliceFromThunk evaluates an array of strings.
+func strSliceFromThunk(thk *states.Thunk) ([]string, error) {
+	var strs []string
+	iter := states.IterFromThunk(thk)
+	for {
+		el, ok, err := iter()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return strs, nil
+		}
+		str, err := el.EvalStr()
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, str)
+	}
+}
+
+// writeFileKernel makes the kernel of a funcer that writes its input to the
+// file given as argument, opening it with the given flags.
+func writeFileKernel(flag int) shapes.RegularKernel {
+	return func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+		return states.ThunkFromFunc(func() *states.Thunk {
+			path, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			val, err := inputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			file, err := os.OpenFile(path, flag, 0o666)
+			if err != nil {
+				return states.ThunkFromError(fileError(pos, path, err))
+			}
+			w := bufio.NewWriter(file)
+			err = writeStrs(w, val)
+			if err == nil {
+				// bufio.Writer remembers the first write error
+				if err = w.Flush(); err != nil {
+					err = fileError(pos, path, err)
+				}
+			}
+			if closeErr := file.Close(); err == nil && closeErr != nil {
+				err = fileError(pos, path, closeErr)
+			}
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.NullValue{})
+		})
+	}
+}
+
+// writeStrs writes a string as is, or the elements of an array of strings
+// each followed by a line break. Write errors are left for w.Flush to report.
+func writeStrs(w *bufio.Writer, val states.Value) error {
+	switch val := val.(type) {
+	case states.StrValue:
+		w.WriteString(string(val))
+	case *states.ArrValue:
+		iter := states.IterFromThunk(states.ThunkFromValue(val))
+		for {
+			thk, ok, err := iter()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			line, err := thk.EvalStr()
+			if err != nil {
+				return err
+			}
+			w.WriteString(line)
+			w.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+// fileError makes an error for a failed file system operation on path.
+func fileError(pos lexer.Position, path string, err error) error {
+	return errors.ValueError(
+		errors.Code(errors.UnexpectedValue),
+		errors.Pos(pos),
+		errors.GotValue(states.StrValue(path)),
+		errors.Message(err.Error()),
+	)
+}
+
+// strArr makes an array value from a slice of strings.
+func strArr(strs []string) *states.ArrValue {
+	elements := make([]states.Value, len(strs))
+	for i, str := range strs {
+		elements[i] = states.StrValue(str)
+	}
+	return states.ArrFromSlice(elements)
+}
+
+// closingReader reads from a file and closes it when the end is reached.
+type closingReader struct {
+	*os.File
+}
+
+func (r closingReader) Read(p []byte) (int, error) {
+	n, err := r.File.Read(p)
+	if err == io.EOF {
+		r.File.Close()
+	}
+	return n, err
+}
+
+// openedFile is a file opened for writing by a Bach program. Unlike other
+// Writers, it is closed by close.
+type openedFile struct {
+	*os.File
+}
+
+// fileWriterKernel makes the kernel of a funcer that opens the file given as
+// input for writing, with the given flags.
+func fileWriterKernel(flag int) shapes.RegularKernel {
+	return func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+		return states.ThunkFromFunc(func() *states.Thunk {
+			path, err := inputState.Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			file, err := os.OpenFile(path, flag, 0o666)
+			if err != nil {
+				return states.ThunkFromError(fileError(pos, path, err))
+			}
+			return states.ThunkFromValue(states.WriterValue{Writer: openedFile{file}})
+		})
+	}
+}
+
+// writeKernel makes the kernel of a funcer that writes its input to the
+// Writer given as first argument, followed by a line break or, if withEnd is
+// true, by the second argument.
+func writeKernel(withEnd bool) shapes.RegularKernel {
+	return func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+		return states.ThunkFromFunc(func() *states.Thunk {
+			val, err := inputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			str, err := val.Str()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			w, err := args[0](inputState.Clear(), nil).Thunk.EvalWriter()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			end := "\n"
+			if withEnd {
+				end, err = args[1](inputState.Clear(), nil).Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+			}
+			_, err = io.WriteString(w, str+end)
+			if err != nil {
+				return states.ThunkFromError(errors.ValueError(
+					errors.Code(errors.UnexpectedValue),
+					errors.Pos(pos),
+					errors.Message(err.Error()),
+				))
+			}
+			return states.ThunkFromValue(states.NullValue{})
+		})
+	}
+}
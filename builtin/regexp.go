@@ -114,6 +114,7 @@ var RegexpFuncers = []shapes.Funcer{
 				Description: "takes a match and returns a string",
 				Params:      nil,
 				OutputType:  types.Str{},
+				Callback:    true,
 			},
 		},
 		OutputType:        types.Str{},
@@ -195,6 +196,7 @@ var RegexpFuncers = []shapes.Funcer{
 				Description: "takes a match and returns a string",
 				Params:      nil,
 				OutputType:  types.Str{},
+				Callback:    true,
 			},
 		},
 		OutputType:        types.Str{},
@@ -2,10 +2,16 @@ package builtin
 
 import (
 	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/params"
 	"github.com/texttheater/bach/shapes"
 	"github.com/texttheater/bach/states"
@@ -293,6 +299,63 @@ var TextFuncers = []shapes.Funcer{
 			{`[65533] codePointsToStr`, `Str`, `"�"`, nil},
 		},
 	},
+	shapes.SimpleFuncer(
+		"Checks whether a string contains a specific substring.",
+		types.Str{},
+		"a string",
+		"contains",
+		[]*params.Param{
+			params.SimpleParam("needle", "a substring to look for", types.Str{}),
+		},
+		types.Bool{},
+		"true if needle occurs somewhere in the input, false otherwise",
+		"",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str1, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			str2, err := argumentThunks[0].EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.BoolValue(strings.Contains(str1, str2)))
+		},
+		[]shapes.Example{
+			{`"abc" contains("bc")`, `Bool`, `true`, nil},
+			{`"abc" contains("ac")`, `Bool`, `false`, nil},
+			{`"abc" contains("")`, `Bool`, `true`, nil},
+		},
+	),
+	shapes.SimpleFuncer(
+		"Counts the occurrences of a substring.",
+		types.Str{},
+		"a string",
+		"count",
+		[]*params.Param{
+			params.SimpleParam("needle", "a substring to look for", types.Str{}),
+		},
+		types.Num{},
+		"the number of non-overlapping occurrences of needle in the input",
+		"If needle is empty, returns 1 plus the number of characters (code points) in the input.",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str1, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			str2, err := argumentThunks[0].EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.NumValue(strings.Count(str1, str2)))
+		},
+		[]shapes.Example{
+			{`"cheese" count("e")`, `Num`, `3`, nil},
+			{`"aaaa" count("aa")`, `Num`, `2`, nil},
+			{`"five" count("")`, `Num`, `5`, nil},
+			{`"Köln" count("")`, `Num`, `5`, nil},
+		},
+	),
 	shapes.Funcer{
 		Summary:           "Splits a string around whitespace.",
 		InputType:         types.Str{},
@@ -323,6 +386,98 @@ var TextFuncers = []shapes.Funcer{
 			{`"  foo bar  baz   " fields`, `Arr<Str...>`, `["foo", "bar", "baz"]`, nil},
 		},
 	},
+	shapes.Funcer{
+		Summary:          "Formats values according to a printf-style template.",
+		InputType:        types.Str{},
+		InputDescription: "a template",
+		Name:             "format",
+		Params: []*params.Param{
+			params.SimpleParam("args", "the values to insert into the template", types.AnyArr),
+		},
+		OutputType:        types.Str{},
+		OutputDescription: "the template with each placeholder replaced by the corresponding formatted value",
+		Notes: "Each placeholder in the template consumes one value from args and has the form `%[flags][width][.precision]verb`. " +
+			"The verbs are `s` (any value, as with toStr), `q` (any value, as a Bach literal), `d` (a number, truncated to an integer), " +
+			"`f`, `e`, `g` (a number in fixed-point, exponential, or shortest notation), and `x`, `X`, `o`, `b` (a number, truncated to an integer, in base 16, 8, or 2). " +
+			"`%%` stands for a literal percent sign. " +
+			"The width is the minimum number of characters; shorter values are padded with spaces on the left. " +
+			"The precision is the number of digits after the decimal point for `f` and `e`, the number of significant digits for `g`, and the maximum number of characters for `s` and `q`. " +
+			"The flags are `-` (pad on the right instead of the left), `0` (pad numbers with zeros), `+` (always print a sign for numbers), " +
+			"` ` (print a space instead of a plus sign for non-negative numbers), and `,` (separate thousands with commas for `d` and `f`). " +
+			"Width and precision can be at most 1000. " +
+			"It is an error if the number of placeholders and values differ or if a value does not fit its verb.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				template, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				argThunk := args[0](inputState.Clear(), nil).Thunk
+				argThunks, err := states.SliceFromThunk(argThunk)
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				argValues := make([]states.Value, len(argThunks))
+				for i, thk := range argThunks {
+					argValues[i], err = thk.Eval()
+					if err != nil {
+						return states.ThunkFromError(err)
+					}
+				}
+				result, err := format(template, argValues)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Code(errors.UnexpectedValue),
+						errors.Pos(pos),
+						errors.GotValue(states.StrValue(template)),
+						errors.Message(err.Error()),
+					))
+				}
+				return states.ThunkFromValue(states.StrValue(result))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"%s has %d items" format(["cart", 3])`, `Str`, `"cart has 3 items"`, nil},
+			{`"|%-6s|%6s|" format(["ab", "cd"])`, `Str`, `"|ab    |    cd|"`, nil},
+			{`"%.2f %8.3f %08.3f" format([3.14159, 2.5, -2.5])`, `Str`, `"3.14    2.500 -002.500"`, nil},
+			{`"%,d %,.2f" format([1234567, 9876.5])`, `Str`, `"1,234,567 9,876.50"`, nil},
+			{`"%+d % d %x %X %o %b" format([5, 5, 255, 255, 8, 5])`, `Str`, `"+5  5 ff FF 10 101"`, nil},
+			{`"%e %g %g" format([1234.5, 0.0001, 100])`, `Str`, `"1.234500e+03 0.0001 100"`, nil},
+			{`"%q %.3s %%" format(["a", "Köln"])`, `Str`, `"\"a\" Köl %"`, nil},
+			{`"%5.1f|%-5d|" format([inf, -3])`, `Str`, `"  inf|-3   |"`, nil},
+			{`"%d" format(["a"])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%d")),
+				errors.Message(`argument 1 for %d must be a number, got "a"`),
+			)},
+			{`"%99999999999999d" format([1])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%99999999999999d")),
+				errors.Message("width must be at most 1000"),
+			)},
+			{`"%.99999999999999999999f" format([1])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%.99999999999999999999f")),
+				errors.Message("precision must be at most 1000"),
+			)},
+			{`"%s %s" format(["a"])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%s %s")),
+				errors.Message("not enough arguments: template has more placeholders than the 1 argument(s) given"),
+			)},
+			{`"%s" format(["a", "b"])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%s")),
+				errors.Message("too many arguments: template has 1 placeholder(s), but 2 arguments were given"),
+			)},
+			{`"%y" format([1])`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("%y")),
+				errors.Message("unknown verb %y"),
+			)},
+		},
+	},
 	shapes.Funcer{
 		Summary:          "Finds the position of a string within another.",
 		InputType:        types.Str{},
@@ -432,6 +587,36 @@ var TextFuncers = []shapes.Funcer{
 			{`for Any def f Arr<Str...> as [] ok f join(";")`, `Str`, `""`, nil},
 		},
 	),
+	shapes.Funcer{
+		Summary:          "Finds the last position of a string within another.",
+		InputType:        types.Str{},
+		InputDescription: "string to search inside",
+		Name:             "lastIndexOf",
+		Params: []*params.Param{
+			params.SimpleParam("needle", "string to search for", types.Str{}),
+		},
+		OutputType:        types.Num{},
+		OutputDescription: "offset of last occurrence of needle from the beginning of the input, measured in bytes, or -1 if none",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			haystack, err := inputState.Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			needle, err := args[0](inputState.Clear(), nil).Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			result := states.NumValue(strings.LastIndex(haystack, needle))
+			return states.ThunkFromValue(result)
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"abcabc" lastIndexOf("bc")`, `Num`, `4`, nil},
+			{`"abc" lastIndexOf("a")`, `Num`, `0`, nil},
+			{`"abc" lastIndexOf("d")`, `Num`, `-1`, nil},
+			{`"Kölner Köpfe" lastIndexOf("ö")`, `Num`, `9`, nil},
+		},
+	},
 	shapes.SimpleFuncer(
 		"Right-pads a string.",
 		types.Str{},
@@ -781,6 +966,135 @@ var TextFuncers = []shapes.Funcer{
 			{`"abc" slice(4, 4)`, `Str`, `""`, nil},
 		},
 	),
+	shapes.SimpleFuncer(
+		"Splits a string around a separator.",
+		types.Str{},
+		"a string",
+		"split",
+		[]*params.Param{
+			params.SimpleParam("sep", "the separator", types.Str{}),
+		},
+		types.NewArr(types.Str{}),
+		"the parts of the input between occurrences of sep",
+		"If sep is empty, the input is split into individual characters (code points).",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			sep, err := argumentThunks[0].EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(strArr(strings.Split(str, sep)))
+		},
+		[]shapes.Example{
+			{`"a,b,c" split(",")`, `Arr<Str...>`, `["a", "b", "c"]`, nil},
+			{`"a, b,,c" split(",")`, `Arr<Str...>`, `["a", " b", "", "c"]`, nil},
+			{`"abc" split(";")`, `Arr<Str...>`, `["abc"]`, nil},
+			{`"" split(";")`, `Arr<Str...>`, `[""]`, nil},
+			{`"Köln" split("")`, `Arr<Str...>`, `["K", "ö", "l", "n"]`, nil},
+		},
+	),
+	shapes.SimpleFuncer(
+		"Splits a string around a separator, up to a maximum number of parts.",
+		types.Str{},
+		"a string",
+		"splitN",
+		[]*params.Param{
+			params.SimpleParam("sep", "the separator", types.Str{}),
+			params.SimpleParam("n", "the maximum number of parts (will be truncated)", types.Num{}),
+		},
+		types.NewArr(types.Str{}),
+		"the parts of the input between the first n-1 occurrences of sep, followed by the rest of the input",
+		"If n is negative, there is no maximum, as with split. If n is 0, the result is empty.",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			sep, err := argumentThunks[0].EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			n, err := argumentThunks[1].EvalInt()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(strArr(strings.SplitN(str, sep, n)))
+		},
+		[]shapes.Example{
+			{`"key=value=more" splitN("=", 2)`, `Arr<Str...>`, `["key", "value=more"]`, nil},
+			{`"a,b,c" splitN(",", 5)`, `Arr<Str...>`, `["a", "b", "c"]`, nil},
+			{`"a,b,c" splitN(",", -1)`, `Arr<Str...>`, `["a", "b", "c"]`, nil},
+			{`"a,b,c" splitN(",", 0)`, `Arr<Str...>`, `[]`, nil},
+		},
+	),
+	shapes.SimpleFuncer(
+		"Converts a string to lower case.",
+		types.Str{},
+		"a string",
+		"toLower",
+		nil,
+		types.Str{},
+		"the input with all letters mapped to lower case",
+		"",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.StrValue(strings.ToLower(str)))
+		},
+		[]shapes.Example{
+			{`"Hello World" toLower`, `Str`, `"hello world"`, nil},
+			{`"KÖLN" toLower`, `Str`, `"köln"`, nil},
+			{`"ΑΘΗΝΑ" toLower`, `Str`, `"αθηνα"`, nil},
+		},
+	),
+	shapes.SimpleFuncer(
+		"Converts a string to title case.",
+		types.Str{},
+		"a string",
+		"toTitle",
+		nil,
+		types.Str{},
+		"the input with the first letter of each word mapped to title case and all other letters mapped to lower case",
+		"A word starts with any letter that does not follow another letter, a digit, or an apostrophe.",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.StrValue(toTitle(str)))
+		},
+		[]shapes.Example{
+			{`"hello world" toTitle`, `Str`, `"Hello World"`, nil},
+			{`"jEAN-luc's ÉCOLE" toTitle`, `Str`, `"Jean-Luc's École"`, nil},
+			{`"ǆungla 2nd" toTitle`, `Str`, `"ǅungla 2nd"`, nil},
+		},
+	),
+	shapes.SimpleFuncer(
+		"Converts a string to upper case.",
+		types.Str{},
+		"a string",
+		"toUpper",
+		nil,
+		types.Str{},
+		"the input with all letters mapped to upper case",
+		"",
+		func(inputThunk *states.Thunk, argumentThunks []*states.Thunk) *states.Thunk {
+			str, err := inputThunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.StrValue(strings.ToUpper(str)))
+		},
+		[]shapes.Example{
+			{`"Hello World" toUpper`, `Str`, `"HELLO WORLD"`, nil},
+			{`"Köln" toUpper`, `Str`, `"KÖLN"`, nil},
+		},
+	),
 	shapes.SimpleFuncer(
 		"Removes whitespace from the start and end of a string.",
 		types.Str{},
@@ -842,3 +1156,202 @@ var TextFuncers = []shapes.Funcer{
 		},
 	),
 }
+
+// toTitle maps the first letter of each word to title case and all other
+// letters to lower case.
+func toTitle(str string) string {
+	var builder strings.Builder
+	inWord := false
+	for _, r := range str {
+		if inWord {
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(unicode.ToTitle(r))
+		}
+		inWord = unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’'
+	}
+	return builder.String()
+}
+
+// maxFormatSize is the largest width or precision that format accepts.
+const maxFormatSize = 1000
+
+// format implements the format funcer.
+func format(template string, args []states.Value) (string, error) {
+	var builder strings.Builder
+	runes := []rune(template)
+	n := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			builder.WriteRune(runes[i])
+			continue
+		}
+		start := i
+		i++
+		// flags
+		var left, zero, plus, space, comma bool
+	flags:
+		for ; i < len(runes); i++ {
+			switch runes[i] {
+			case '-':
+				left = true
+			case '0':
+				zero = true
+			case '+':
+				plus = true
+			case ' ':
+				space = true
+			case ',':
+				comma = true
+			default:
+				break flags
+			}
+		}
+		// width
+		width := 0
+		for ; i < len(runes) && isDigit(runes[i]); i++ {
+			width = width*10 + int(runes[i]-'0')
+			if width > maxFormatSize {
+				return "", fmt.Errorf("width must be at most %d", maxFormatSize)
+			}
+		}
+		// precision
+		precision := -1
+		if i < len(runes) && runes[i] == '.' {
+			precision = 0
+			for i++; i < len(runes) && isDigit(runes[i]); i++ {
+				precision = precision*10 + int(runes[i]-'0')
+				if precision > maxFormatSize {
+					return "", fmt.Errorf("precision must be at most %d", maxFormatSize)
+				}
+			}
+		}
+		if i >= len(runes) {
+			return "", fmt.Errorf("incomplete placeholder %s", string(runes[start:]))
+		}
+		verb := runes[i]
+		if verb == '%' {
+			builder.WriteRune('%')
+			continue
+		}
+		if !strings.ContainsRune("sqdfegxXob", verb) {
+			return "", fmt.Errorf("unknown verb %s", string(runes[start:i+1]))
+		}
+		if n >= len(args) {
+			return "", fmt.Errorf("not enough arguments: template has more placeholders than the %d argument(s) given", len(args))
+		}
+		arg := args[n]
+		n++
+		var str string
+		finite := false
+		switch verb {
+		case 's', 'q':
+			var err error
+			if verb == 's' {
+				str, err = arg.Str()
+			} else {
+				str, err = arg.Repr()
+			}
+			if err != nil {
+				return "", err
+			}
+			if precision >= 0 && utf8.RuneCountInString(str) > precision {
+				str = string([]rune(str)[:precision])
+			}
+		default:
+			num, ok := arg.(states.NumValue)
+			if !ok {
+				repr, err := arg.Repr()
+				if err != nil {
+					return "", err
+				}
+				return "", fmt.Errorf("argument %d for %s must be a number, got %s", n, string(runes[start:i+1]), repr)
+			}
+			str = formatNum(float64(num), verb, precision, plus, space, comma)
+			finite = !math.IsNaN(float64(num)) && !math.IsInf(float64(num), 0)
+		}
+		builder.WriteString(pad(str, width, left, zero && finite))
+	}
+	if n < len(args) {
+		return "", fmt.Errorf("too many arguments: template has %d placeholder(s), but %d arguments were given", n, len(args))
+	}
+	return builder.String(), nil
+}
+
+// formatNum formats a number for one of the numeric verbs of format.
+func formatNum(f float64, verb rune, precision int, plus bool, space bool, comma bool) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		repr, _ := states.NumValue(f).Repr()
+		return repr
+	}
+	sign := ""
+	if f < 0 || (f == 0 && math.Signbit(f) && verb != 'd') {
+		sign = "-"
+		f = -f
+	} else if plus {
+		sign = "+"
+	} else if space {
+		sign = " "
+	}
+	var digits string
+	switch verb {
+	case 'd':
+		digits = strconv.FormatFloat(math.Trunc(f), 'f', 0, 64)
+	case 'f', 'e':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(f, byte(verb), precision, 64)
+	case 'g':
+		if precision == 0 {
+			precision = 1
+		}
+		digits = strconv.FormatFloat(f, 'g', precision, 64)
+	case 'x', 'X', 'o', 'b':
+		base := map[rune]int{'x': 16, 'X': 16, 'o': 8, 'b': 2}[verb]
+		i, _ := new(big.Float).SetFloat64(math.Trunc(f)).Int(nil)
+		digits = i.Text(base)
+		if verb == 'X' {
+			digits = strings.ToUpper(digits)
+		}
+	}
+	if comma && (verb == 'd' || verb == 'f') {
+		intPart, fracPart, _ := strings.Cut(digits, ".")
+		var builder strings.Builder
+		for i, r := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				builder.WriteRune(',')
+			}
+			builder.WriteRune(r)
+		}
+		digits = builder.String()
+		if fracPart != "" {
+			digits += "." + fracPart
+		}
+	}
+	return sign + digits
+}
+
+// pad pads a string to the given width in characters. Zero-padding is meant
+// for formatted numbers and is inserted after the sign, if any.
+func pad(str string, width int, left bool, zero bool) string {
+	n := width - utf8.RuneCountInString(str)
+	if n <= 0 {
+		return str
+	}
+	if left {
+		return str + strings.Repeat(" ", n)
+	}
+	if zero {
+		sign := ""
+		if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") || strings.HasPrefix(str, " ") {
+			sign, str = str[:1], str[1:]
+		}
+		return sign + strings.Repeat("0", n) + str
+	}
+	return strings.Repeat(" ", n) + str
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
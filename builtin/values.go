@@ -1,15 +1,22 @@
 package builtin
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/params"
 	"github.com/texttheater/bach/shapes"
 	"github.com/texttheater/bach/states"
 	"github.com/texttheater/bach/types"
+	"gopkg.in/yaml.v2"
 )
 
 var ValueFuncers = []shapes.Funcer{
@@ -228,35 +235,222 @@ var ValueFuncers = []shapes.Funcer{
 					errors.Message(err.Error()),
 				))
 			}
-			return thunkFromData(data, pos)
+			return ThunkFromData(data, pos)
 		},
 		IDs: nil,
 	},
 	shapes.Funcer{
-		InputType:  types.Any{},
-		Name:       "toJSON",
-		Params:     nil,
-		OutputType: types.Str{},
+		Summary:           "Parses a TOML document.",
+		InputType:         types.Str{},
+		InputDescription:  "a string containing a TOML document",
+		Name:              "parseTOML",
+		Params:            nil,
+		OutputType:        types.AnyObj,
+		OutputDescription: "the table represented by the document",
+		Notes:             "Integers and floats become numbers. Dates and times become strings in RFC 3339 format, or the corresponding partial format for local dates and times.",
 		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
-			val, err := inputState.Thunk.Eval()
-			if err != nil {
-				return states.ThunkFromError(err)
-			}
-			data, err := val.Data()
-			if err != nil {
-				return states.ThunkFromError(err)
-			}
-			bytes, err := json.Marshal(data)
-			if err != nil {
-				return states.ThunkFromError(errors.ValueError(
-					errors.Pos(pos),
-					errors.Code(errors.UnexpectedValue),
-					errors.Message(err.Error()),
-				))
+			return states.ThunkFromFunc(func() *states.Thunk {
+				str, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				var data map[string]any
+				err = toml.Unmarshal([]byte(str), &data)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Pos(pos),
+						errors.Code(errors.UnexpectedValue),
+						errors.GotValue(states.StrValue(str)),
+						errors.Message(err.Error()),
+					))
+				}
+				return thunkFromForeignData(data, pos)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"title = \"TOML\"\n[[points]]\nx = 1\n[[points]]\nx = 2.5\n" parseTOML`, `Obj<Any>`, `{title: "TOML", points: [{x: 1}, {x: 2.5}]}`, nil},
+			{`"t = 1979-05-27T07:32:00Z\nd = 1979-05-27T07:32:00\nu = 07:32:00\n" parseTOML`, `Obj<Any>`, `{t: "1979-05-27T07:32:00Z", d: "1979-05-27T07:32:00", u: "07:32:00"}`, nil},
+			{`"a = " parseTOML`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("a = ")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Parses a YAML document.",
+		InputType:         types.Str{},
+		InputDescription:  "a string containing a YAML document",
+		Name:              "parseYAML",
+		Params:            nil,
+		OutputType:        types.Any{},
+		OutputDescription: "the data structure represented by the document",
+		Notes:             "Mapping keys that are not strings, such as numbers and booleans, are converted to strings. Mappings with keys that are themselves mappings or sequences cause an error. Use yaml to read streams with several documents.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				str, err := inputState.Thunk.EvalStr()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				var data any
+				err = yaml.Unmarshal([]byte(str), &data)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Pos(pos),
+						errors.Code(errors.UnexpectedValue),
+						errors.GotValue(states.StrValue(str)),
+						errors.Message(err.Error()),
+					))
+				}
+				return thunkFromForeignData(data, pos)
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`"name: Bach\nborn: 1685-03-31\nworks: [BWV 1, BWV 2]\n" parseYAML`, `Any`, `{name: "Bach", born: "1685-03-31", works: ["BWV 1", "BWV 2"]}`, nil},
+			{`"[.inf, 0x10, 1e3]" parseYAML`, `Any`, `[inf, 16, 1000]`, nil},
+			{`"a: [" parseYAML`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("a: [")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Writes records as CSV.",
+		InputType:         types.NewArr(types.NewArr(types.Str{})),
+		InputDescription:  "an array of records, each an array of fields",
+		Name:              "toCSV",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "the records in CSV format, with fields separated by commas and quoted with double quotes where necessary",
+		Notes:             "Each record is followed by a line break.",
+		Kernel:            toCSVKernel,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`[["a", "b"], ["1", "2,3"]] toCSV`, `Str`, `"a,b\n1,\"2,3\"\n"`, nil},
+			{`[["say \"hi\""]] toCSV`, `Str`, `"\"say \"\"hi\"\"\"\n"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Writes records as CSV with a custom delimiter.",
+		InputType:        types.NewArr(types.NewArr(types.Str{})),
+		InputDescription: "an array of records, each an array of fields",
+		Name:             "toCSV",
+		Params: []*params.Param{
+			params.SimpleParam("delimiter", "the character separating fields", types.Str{}),
+		},
+		OutputType:        types.Str{},
+		OutputDescription: "the records in CSV format, with fields separated by delimiter and quoted with double quotes where necessary",
+		Notes:             "Each record is followed by a line break.",
+		Kernel:            toCSVKernel,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`[["a", "b"], ["1", "2;3"]] toCSV(";")`, `Str`, `"a;b\n1;\"2;3\"\n"`, nil},
+			{`[["a"]] toCSV("\n")`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.StrValue("\n")),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Writes records as CSV with a custom delimiter and quote character.",
+		InputType:        types.NewArr(types.NewArr(types.Str{})),
+		InputDescription: "an array of records, each an array of fields",
+		Name:             "toCSV",
+		Params: []*params.Param{
+			params.SimpleParam("delimiter", "the character separating fields", types.Str{}),
+			params.SimpleParam("quote", "the character for quoting fields", types.Str{}),
+		},
+		OutputType:        types.Str{},
+		OutputDescription: "the records in CSV format, with fields separated by delimiter and quoted with quote where necessary",
+		Notes:             "Each record is followed by a line break.",
+		Kernel:            toCSVKernel,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`[["a", "b|c", "\"d\""]] toCSV("|", "'")`, `Str`, `"a|'b|c'|\"d\"\n"`, nil},
+			{`[["a", "b|c", "\"d\""]] toCSV("|", "'") reader csv("|", "'")`, `Arr<Arr<Str...>...>`, `[["a", "b|c", "\"d\""]]`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Serializes a value as JSON.",
+		InputType:         types.Any{},
+		InputDescription:  "a value",
+		Name:              "toJSON",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "the value in compact JSON format",
+		Notes:             "Object keys are sorted. Values that have no JSON representation, namely nan, infinite numbers, readers, and writers, cause an error.",
+		Kernel:            toJSONKernel,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`{b: [1, 2], a: "x"} toJSON`, `Str`, `"{{\"a\":\"x\",\"b\":[1,2]}}"`, nil},
+			{`[1, inf] toJSON`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.NumValue(math.Inf(1))),
+				errors.Message("inf cannot be represented in JSON"),
+			)},
+			{`"a" reader toJSON`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Message("readers cannot be represented in JSON"),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:          "Serializes a value as indented JSON.",
+		InputType:        types.Any{},
+		InputDescription: "a value",
+		Name:             "toJSON",
+		Params: []*params.Param{
+			params.SimpleParam("indent", "the string to indent each level with, e.g., two spaces or a tab", types.Str{}),
+		},
+		OutputType:        types.Str{},
+		OutputDescription: "the value in JSON format, with each array element and object property on its own line",
+		Notes:             "Object keys are sorted. Values that have no JSON representation, namely nan, infinite numbers, readers, and writers, cause an error.",
+		Kernel:            toJSONKernel,
+		IDs:               nil,
+		Examples: []shapes.Example{
+			{`{b: [1, 2], a: "x"} toJSON("  ")`, `Str`, `"{{\n  \"a\": \"x\",\n  \"b\": [\n    1,\n    2\n  ]\n}}"`, nil},
+			{`[] toJSON("\t")`, `Str`, `"[]"`, nil},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Serializes values as newline-delimited JSON.",
+		InputType:         types.NewArr(types.Any{}),
+		InputDescription:  "an array of values",
+		Name:              "toNDJSON",
+		Params:            nil,
+		OutputType:        types.NewArr(types.Str{}),
+		OutputDescription: "an array of lines, each containing one value in compact JSON format",
+		Notes:             "Unlike with toJSON, each value is serialized separately, so the lines can be written out one by one, e.g., with writeFile. Values that have no JSON representation cause an error like with toJSON.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			input := states.IterFromThunk(inputState.Thunk)
+			iter := func() (*states.Thunk, bool, error) {
+				thk, ok, err := input()
+				if !ok || err != nil {
+					return nil, false, err
+				}
+				val, err := thk.Eval()
+				if err != nil {
+					return nil, false, err
+				}
+				str, err := marshalJSON(val, "", pos)
+				if err != nil {
+					return nil, false, err
+				}
+				return states.ThunkFromValue(states.StrValue(str)), true, nil
 			}
-			return states.ThunkFromValue(states.StrValue(bytes))
+			return states.ThunkFromFunc(func() *states.Thunk {
+				return states.ThunkFromIter(iter)
+			})
 		},
 		IDs: nil,
+		Examples: []shapes.Example{
+			{`[{a: 1}, [2, null], "3"] toNDJSON`, `Arr<Str...>`, `["{{\"a\":1}}", "[2,null]", "\"3\""]`, nil},
+			{`[1, nan] toNDJSON`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.Message("nan cannot be represented in JSON"),
+			)},
+		},
 	},
 	shapes.Funcer{
 		InputType: types.NewArr(types.NewTup([]types.Type{types.Str{}, types.NewVar("A", types.Any{})})),
@@ -313,9 +507,91 @@ var ValueFuncers = []shapes.Funcer{
 		},
 		IDs: nil,
 	},
+	shapes.Funcer{
+		Summary:           "Serializes an object as TOML.",
+		InputType:         types.AnyObj,
+		InputDescription:  "an object",
+		Name:              "toTOML",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "a TOML document representing the object as a table",
+		Notes:             "Keys are sorted. Numbers without a fractional part are written as integers. Values that have no TOML representation, namely null, readers, and writers, cause an error.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				val, err := inputState.Thunk.Eval()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				data, err := exportData(val, "TOML", pos)
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				var buffer strings.Builder
+				enc := toml.NewEncoder(&buffer)
+				enc.Indent = ""
+				err = enc.Encode(data)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Pos(pos),
+						errors.Code(errors.UnexpectedValue),
+						errors.GotValue(val),
+						errors.Message(err.Error()),
+					))
+				}
+				return states.ThunkFromValue(states.StrValue(buffer.String()))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`{title: "x", server: {port: 8080, ratio: 0.5}} toTOML`, `Str`, `"title = \"x\"\n\n[server]\nport = 8080\nratio = 0.5\n"`, nil},
+			{`{a: null} toTOML`, ``, ``, errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(states.NullValue{}),
+				errors.Message("null cannot be represented in TOML"),
+			)},
+		},
+	},
+	shapes.Funcer{
+		Summary:           "Serializes a value as YAML.",
+		InputType:         types.Any{},
+		InputDescription:  "a value",
+		Name:              "toYAML",
+		Params:            nil,
+		OutputType:        types.Str{},
+		OutputDescription: "a YAML document representing the value",
+		Notes:             "Keys are sorted. Values that have no YAML representation, namely readers and writers, cause an error.",
+		Kernel: func(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+			return states.ThunkFromFunc(func() *states.Thunk {
+				val, err := inputState.Thunk.Eval()
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				data, err := exportData(val, "YAML", pos)
+				if err != nil {
+					return states.ThunkFromError(err)
+				}
+				bytes, err := yaml.Marshal(data)
+				if err != nil {
+					return states.ThunkFromError(errors.ValueError(
+						errors.Pos(pos),
+						errors.Code(errors.UnexpectedValue),
+						errors.GotValue(val),
+						errors.Message(err.Error()),
+					))
+				}
+				return states.ThunkFromValue(states.StrValue(bytes))
+			})
+		},
+		IDs: nil,
+		Examples: []shapes.Example{
+			{`{name: "Bach", works: [1, 2.5], nan: nan} toYAML`, `Str`, `"name: Bach\nnan: .nan\nworks:\n- 1\n- 2.5\n"`, nil},
+			{`{name: "Bach", works: [1, 2.5]} toYAML parseYAML`, `Any`, `{name: "Bach", works: [1, 2.5]}`, nil},
+		},
+	},
 }
 
-func thunkFromData(data any, pos lexer.Position) *states.Thunk {
+// ThunkFromData converts data as decoded by encoding/json to a Bach value.
+func ThunkFromData(data any, pos lexer.Position) *states.Thunk {
 	switch data := data.(type) {
 	case nil:
 		return states.ThunkFromValue(states.NullValue{})
@@ -331,7 +607,7 @@ func thunkFromData(data any, pos lexer.Position) *states.Thunk {
 			if i >= len(data) {
 				return nil, false, nil
 			}
-			thk := thunkFromData(data[i], pos)
+			thk := ThunkFromData(data[i], pos)
 			i += 1
 			return thk, true, nil
 		}
@@ -339,7 +615,7 @@ func thunkFromData(data any, pos lexer.Position) *states.Thunk {
 	case map[string]any:
 		obj := make(map[string]*states.Thunk)
 		for k, v := range data {
-			thk := thunkFromData(v, pos)
+			thk := ThunkFromData(v, pos)
 			obj[k] = thk
 		}
 		return states.ThunkFromValue(states.ObjValue(obj))
@@ -351,3 +627,333 @@ func thunkFromData(data any, pos lexer.Position) *states.Thunk {
 		))
 	}
 }
+
+// TypeFromData returns the most specific type of data as decoded by
+// encoding/json, i.e., the type a literal for the same value would have.
+func TypeFromData(data any) types.Type {
+	switch data := data.(type) {
+	case nil:
+		return types.Null{}
+	case bool:
+		return types.Bool{}
+	case float64:
+		return types.Num{}
+	case string:
+		return types.Str{}
+	case []any:
+		elementTypes := make([]types.Type, len(data))
+		for i, element := range data {
+			elementTypes[i] = TypeFromData(element)
+		}
+		return types.NewTup(elementTypes)
+	case map[string]any:
+		props := make(map[string]types.Type)
+		for k, v := range data {
+			props[k] = TypeFromData(v)
+		}
+		return types.Obj{
+			Props: props,
+			Rest:  types.Void{},
+		}
+	default:
+		return types.Any{}
+	}
+}
+
+func toCSVKernel(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+	return states.ThunkFromFunc(func() *states.Thunk {
+		comma, quote, err := csvDialect(inputState, args, pos)
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		var buffer strings.Builder
+		w := csv.NewWriter(&buffer)
+		w.Comma = comma
+		iter := states.IterFromThunk(inputState.Thunk)
+		for {
+			thk, ok, err := iter()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			if !ok {
+				break
+			}
+			record, err := strSliceFromThunk(thk)
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			if quote != '"' {
+				for i, field := range record {
+					record[i] = swapQuotes(field, quote)
+				}
+			}
+			err = w.Write(record)
+			if err != nil {
+				return states.ThunkFromError(csvWriteError(pos, err))
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return states.ThunkFromError(csvWriteError(pos, err))
+		}
+		output := buffer.String()
+		if quote != '"' {
+			output = swapQuotes(output, quote)
+		}
+		return states.ThunkFromValue(states.StrValue(output))
+	})
+}
+
+// csvWriteError makes an error for a failure to write CSV.
+func csvWriteError(pos lexer.Position, err error) error {
+	return errors.ValueError(
+		errors.Code(errors.UnexpectedValue),
+		errors.Pos(pos),
+		errors.Message(err.Error()),
+	)
+}
+
+func toJSONKernel(inputState states.State, args []states.Action, bindings map[string]types.Type, pos lexer.Position) *states.Thunk {
+	return states.ThunkFromFunc(func() *states.Thunk {
+		indent := ""
+		if len(args) > 0 {
+			var err error
+			indent, err = args[0](inputState.Clear(), nil).Thunk.EvalStr()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+		}
+		val, err := inputState.Thunk.Eval()
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		str, err := marshalJSON(val, indent, pos)
+		if err != nil {
+			return states.ThunkFromError(err)
+		}
+		return states.ThunkFromValue(states.StrValue(str))
+	})
+}
+
+// marshalJSON serializes a value as JSON, indenting it with the given string
+// if it is not empty.
+func marshalJSON(val states.Value, indent string, pos lexer.Position) (string, error) {
+	data, err := JSONData(val, pos)
+	if err != nil {
+		return "", err
+	}
+	var bytes []byte
+	if indent == "" {
+		bytes, err = json.Marshal(data)
+	} else {
+		bytes, err = json.MarshalIndent(data, "", indent)
+	}
+	if err != nil {
+		return "", errors.ValueError(
+			errors.Pos(pos),
+			errors.Code(errors.UnexpectedValue),
+			errors.GotValue(val),
+			errors.Message(err.Error()),
+		)
+	}
+	return string(bytes), nil
+}
+
+// JSONData converts a value to data that can be serialized as JSON. Unlike
+// Value.Data, it returns an error for values that have no JSON
+// representation, namely NaN, infinite numbers, readers, and writers.
+func JSONData(val states.Value, pos lexer.Position) (any, error) {
+	return exportData(val, "JSON", pos)
+}
+
+// exportData converts a value to data that can be serialized in the given
+// format ("JSON", "YAML", or "TOML"), returning an error for values that have
+// no representation in that format.
+func exportData(val states.Value, format string, pos lexer.Position) (any, error) {
+	unrepresentable := func(what string) error {
+		return errors.ValueError(
+			errors.Pos(pos),
+			errors.Code(errors.UnexpectedValue),
+			errors.GotValue(val),
+			errors.Message(what+" cannot be represented in "+format),
+		)
+	}
+	switch val := val.(type) {
+	case states.NullValue:
+		if format == "TOML" {
+			return nil, unrepresentable("null")
+		}
+		return nil, nil
+	case states.NumValue:
+		f := float64(val)
+		if format == "JSON" && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			repr, _ := val.Repr()
+			return nil, unrepresentable(repr)
+		}
+		if format == "TOML" && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int64(f), nil
+		}
+		return f, nil
+	case states.ReaderValue:
+		return nil, unrepresentable("readers")
+	case states.WriterValue:
+		return nil, unrepresentable("writers")
+	case *states.ArrValue:
+		res := make([]any, 0)
+		for val != nil {
+			head, err := val.Head.Eval()
+			if err != nil {
+				return nil, err
+			}
+			data, err := exportData(head, format, pos)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, data)
+			val, err = val.Tail.EvalArr()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case states.ObjValue:
+		res := make(map[string]any, len(val))
+		for k, thk := range val {
+			prop, err := thk.Eval()
+			if err != nil {
+				return nil, err
+			}
+			data, err := exportData(prop, format, pos)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = data
+		}
+		return res, nil
+	default:
+		return val.Data()
+	}
+}
+
+// thunkFromForeignData converts data as decoded by the YAML or TOML libraries
+// to a Bach value.
+func thunkFromForeignData(data any, pos lexer.Position) *states.Thunk {
+	data, err := normalizeData(data, pos)
+	if err != nil {
+		return states.ThunkFromError(err)
+	}
+	return ThunkFromData(data, pos)
+}
+
+// normalizeData converts data as decoded by the YAML or TOML libraries to the
+// form produced by encoding/json: integers become float64, timestamps become
+// strings, and maps with non-string keys get string keys.
+func normalizeData(data any, pos lexer.Position) (any, error) {
+	switch data := data.(type) {
+	case nil, bool, float64, string:
+		return data, nil
+	case int:
+		return float64(data), nil
+	case int64:
+		return float64(data), nil
+	case uint64:
+		return float64(data), nil
+	case float32:
+		return float64(data), nil
+	case time.Time:
+		return formatTime(data), nil
+	case []any:
+		res := make([]any, len(data))
+		for i, element := range data {
+			element, err := normalizeData(element, pos)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = element
+		}
+		return res, nil
+	case []map[string]any:
+		res := make([]any, len(data))
+		for i, element := range data {
+			element, err := normalizeData(element, pos)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = element
+		}
+		return res, nil
+	case map[string]any:
+		res := make(map[string]any, len(data))
+		for k, v := range data {
+			v, err := normalizeData(v, pos)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = v
+		}
+		return res, nil
+	case map[any]any:
+		res := make(map[string]any, len(data))
+		for k, v := range data {
+			key, err := normalizeKey(k, pos)
+			if err != nil {
+				return nil, err
+			}
+			v, err := normalizeData(v, pos)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = v
+		}
+		return res, nil
+	default:
+		return nil, errors.ValueError(
+			errors.Pos(pos),
+			errors.Code(errors.UnexpectedValue),
+			errors.Message(fmt.Sprintf("unsupported value %v", data)),
+		)
+	}
+}
+
+// normalizeKey converts a scalar map key to a string.
+func normalizeKey(key any, pos lexer.Position) (string, error) {
+	switch key := key.(type) {
+	case string:
+		return key, nil
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(key), nil
+	case int:
+		return strconv.Itoa(key), nil
+	case int64:
+		return strconv.FormatInt(key, 10), nil
+	case uint64:
+		return strconv.FormatUint(key, 10), nil
+	case float64:
+		return states.NumValue(key).Repr()
+	case time.Time:
+		return formatTime(key), nil
+	default:
+		return "", errors.ValueError(
+			errors.Pos(pos),
+			errors.Code(errors.UnexpectedValue),
+			errors.Message(fmt.Sprintf("unsupported mapping key %v", key)),
+		)
+	}
+}
+
+// formatTime formats a timestamp in RFC 3339 format. For TOML local dates,
+// times and date-times, the corresponding partial format is used.
+func formatTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
@@ -29,6 +29,9 @@ const (
 	NoSuchIndex
 	BadIndex
 	NoGetterAllowed
+	ImportFailed
+	ImportCycle
+	AssertionFailed
 )
 
 func (code ErrorCode) String() string {
@@ -77,6 +80,12 @@ func (code ErrorCode) String() string {
 		return "BadIndex"
 	case NoGetterAllowed:
 		return "NoGetterAllowed"
+	case ImportFailed:
+		return "ImportFailed"
+	case ImportCycle:
+		return "ImportCycle"
+	case AssertionFailed:
+		return "AssertionFailed"
 	default:
 		return "Unknown"
 	}
@@ -128,6 +137,12 @@ func (code ErrorCode) DefaultMessage() string {
 		return "Index must be a nonnegative integer."
 	case NoGetterAllowed:
 		return "A getter expression cannot be applied to this type."
+	case ImportFailed:
+		return "The file could not be imported."
+	case ImportCycle:
+		return "Files cannot import each other in a cycle."
+	case AssertionFailed:
+		return "An assertion failed."
 	default:
 		return "unknown error"
 	}
@@ -179,6 +194,12 @@ func ParseCode(s string) (ErrorCode, error) {
 		return BadIndex, nil
 	case "NoGetterAllowed":
 		return NoGetterAllowed, nil
+	case "ImportFailed":
+		return ImportFailed, nil
+	case "ImportCycle":
+		return ImportCycle, nil
+	case "AssertionFailed":
+		return AssertionFailed, nil
 	default:
 		return 0, fmt.Errorf("invalid error code")
 	}
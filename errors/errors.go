@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/params"
@@ -19,7 +18,9 @@ type errorAttribute func(err *E)
 // The following functions can be used to create error attributes:
 //
 //	Code
+//	UserCode
 //	Pos
+//	Span
 //	Message
 //	WantType
 //	GotType
@@ -31,6 +32,8 @@ type errorAttribute func(err *E)
 //	ParamNum
 //	WantParam
 //	GotParam
+//	Hint
+//	ExitCode
 func SyntaxError(atts ...errorAttribute) error {
 	return makeError(SyntaxKind, atts...)
 }
@@ -39,7 +42,9 @@ func SyntaxError(atts ...errorAttribute) error {
 // The following functions can be used to create error attributes:
 //
 //	Code
+//	UserCode
 //	Pos
+//	Span
 //	Message
 //	WantType
 //	GotType
@@ -51,6 +56,8 @@ func SyntaxError(atts ...errorAttribute) error {
 //	ParamNum
 //	WantParam
 //	GotParam
+//	Hint
+//	ExitCode
 func TypeError(atts ...errorAttribute) error {
 	return makeError(TypeKind, atts...)
 }
@@ -59,7 +66,9 @@ func TypeError(atts ...errorAttribute) error {
 // The following functions can be used to create error attributes:
 //
 //	Code
+//	UserCode
 //	Pos
+//	Span
 //	Message
 //	WantType
 //	GotType
@@ -71,6 +80,8 @@ func TypeError(atts ...errorAttribute) error {
 //	ParamNum
 //	WantParam
 //	GotParam
+//	Hint
+//	ExitCode
 func ValueError(atts ...errorAttribute) error {
 	return makeError(ValueKind, atts...)
 }
@@ -79,7 +90,9 @@ func ValueError(atts ...errorAttribute) error {
 // The following functions can be used to create error attributes:
 //
 //	Code
+//	UserCode
 //	Pos
+//	Span
 //	Message
 //	WantType
 //	GotType
@@ -91,6 +104,8 @@ func ValueError(atts ...errorAttribute) error {
 //	ParamNum
 //	WantParam
 //	GotParam
+//	Hint
+//	ExitCode
 func UnknownError(atts ...errorAttribute) error {
 	return makeError(UnknownKind, atts...)
 }
@@ -112,12 +127,37 @@ func Code(code ErrorCode) errorAttribute {
 	}
 }
 
+// UserCode sets an error code defined by a Bach program, e.g., via the fatal
+// funcer. If name is the name of a builtin code, that code is set instead.
+func UserCode(name string) errorAttribute {
+	return func(err *E) {
+		if code, e := ParseCode(name); e == nil {
+			err.Code = &code
+			return
+		}
+		err.UserCode = &name
+	}
+}
+
 func Pos(pos lexer.Position) errorAttribute {
 	return func(err *E) {
 		err.Pos = &pos
 	}
 }
 
+// Span sets the position of the error to the stretch of source code from pos
+// up to, but not including, endPos. A zero endPos is ignored, so the error is
+// then reported at pos only.
+func Span(pos lexer.Position, endPos lexer.Position) errorAttribute {
+	return func(err *E) {
+		err.Pos = &pos
+		if endPos.Line == 0 {
+			return
+		}
+		err.EndPos = &endPos
+	}
+}
+
 func Message(message string) errorAttribute {
 	return func(err *E) {
 		err.Message = &message
@@ -184,17 +224,31 @@ func GotParam(gotParam *params.Param) errorAttribute {
 	}
 }
 
+// Hint sets a hint on how to fix the error. An empty hint is ignored.
 func Hint(hint string) errorAttribute {
 	return func(err *E) {
+		if hint == "" {
+			return
+		}
 		err.Hint = &hint
 	}
 }
 
+func ExitCode(exitCode int) errorAttribute {
+	return func(err *E) {
+		err.ExitCode = &exitCode
+	}
+}
+
 // An E represents any code of Bach error, or error template.
 type E struct {
-	Kind      *ErrorKind
-	Code      *ErrorCode
+	Kind *ErrorKind
+	Code *ErrorCode
+	// UserCode holds the name of the error code if it was defined by a
+	// Bach program rather than being one of the builtin codes.
+	UserCode  *string
 	Pos       *lexer.Position
+	EndPos    *lexer.Position
 	Message   *string
 	WantType  types.Type
 	GotType   types.Type
@@ -207,6 +261,73 @@ type E struct {
 	WantParam *params.Param
 	GotParam  *params.Param
 	Hint      *string
+	ExitCode  *int
+	// Trace holds the calls during which the error occurred, innermost
+	// first. It is only recorded for errors that occur at runtime.
+	Trace []states.Frame
+	// Source holds the contents of the file that Pos refers to if that is
+	// a file imported by the program rather than the program itself.
+	Source *string
+}
+
+// WithSources attaches to err, if it is an E, the source of the file its
+// position refers to, if that file is among the given imported files.
+func WithSources(err error, sources map[string]string) error {
+	e, ok := err.(*E)
+	if !ok || e.Pos == nil {
+		return err
+	}
+	source, ok := sources[e.Pos.Filename]
+	if !ok {
+		return err
+	}
+	withSource := *e
+	withSource.Source = &source
+	return &withSource
+}
+
+// WithCallEnds gives err, if it is an E with a position but no end position,
+// the end position of the call at its position, if that call is among the
+// given ones. Funcers only know where they are called, so this turns the
+// position of a value error they raise into the span of the call.
+func WithCallEnds(err error, callEnds map[lexer.Position]lexer.Position) error {
+	e, ok := err.(*E)
+	if !ok || e.Pos == nil || e.EndPos != nil {
+		return err
+	}
+	endPos, ok := callEnds[*e.Pos]
+	if !ok {
+		return err
+	}
+	withEnd := *e
+	withEnd.EndPos = &endPos
+	return &withEnd
+}
+
+// CallTrace returns the calls during which the error occurred, innermost
+// first.
+func (err *E) CallTrace() []states.Frame {
+	return err.Trace
+}
+
+// WithTrace returns a copy of the error with the given call trace, innermost
+// first.
+func (err *E) WithTrace(trace []states.Frame) error {
+	e := *err
+	e.Trace = trace
+	return &e
+}
+
+// CodeName returns the name of the error's code, or the empty string if it has
+// none.
+func (err *E) CodeName() string {
+	if err.UserCode != nil {
+		return *err.UserCode
+	}
+	if err.Code != nil {
+		return err.Code.String()
+	}
+	return ""
 }
 
 func (err *E) Error() string {
@@ -214,12 +335,15 @@ func (err *E) Error() string {
 	if err.Kind != nil {
 		m["Kind"] = err.Kind.String()
 	}
-	if err.Code != nil {
-		m["Code"] = err.Code.String()
+	if code := err.CodeName(); code != "" {
+		m["Code"] = code
 	}
 	if err.Pos != nil {
 		m["Pos"] = err.Pos.String()
 	}
+	if err.EndPos != nil {
+		m["EndPos"] = err.EndPos.String()
+	}
 	if err.Message != nil {
 		m["Message"] = *err.Message
 	}
@@ -253,6 +377,19 @@ func (err *E) Error() string {
 	if err.GotParam != nil {
 		m["GotParam"] = err.GotParam.String()
 	}
+	if err.Hint != nil {
+		m["Hint"] = *err.Hint
+	}
+	if err.ExitCode != nil {
+		m["ExitCode"] = *err.ExitCode
+	}
+	if len(err.Trace) > 0 {
+		trace := make([]string, len(err.Trace))
+		for i, frame := range err.Trace {
+			trace[i] = traceLine(frame)
+		}
+		m["Trace"] = trace
+	}
 	buffer := bytes.Buffer{}
 	encoder := json.NewEncoder(&buffer)
 	encoder.SetEscapeHTML(false)
@@ -263,6 +400,9 @@ func (err *E) Error() string {
 	return buffer.String()
 }
 
+// Explain writes a human-readable description of err to w. If the error has a
+// position, the offending code is shown, taken from program or, if the
+// position is in an imported file, from the error's Source.
 func Explain(w io.Writer, err error, program string) {
 	e, ok := err.(*E)
 	if !ok {
@@ -274,21 +414,16 @@ func Explain(w io.Writer, err error, program string) {
 	fmt.Fprint(w, e.Kind)
 	if e.Pos != nil && e.Pos.Line > 0 {
 		fmt.Fprintln(w, " error at", e.Pos)
-		lines := strings.SplitAfter(program, "\n")
-		line := lines[e.Pos.Line-1]
-		column := e.Pos.Column
-		// TODO shorten long lines
-		fmt.Fprint(w, line)
-		if len(line) == 0 || line[len(line)-1] != '\n' {
-			fmt.Fprintln(w)
+		source := program
+		if e.Source != nil {
+			source = *e.Source
 		}
-		fmt.Fprint(w, strings.Repeat(" ", column-1))
-		fmt.Fprintln(w, "^")
+		explainSource(w, e, source)
 	} else {
 		fmt.Fprintln(w, " error")
 	}
 	// attributes
-	fmt.Fprintln(w, "Code:      ", e.Code.String())
+	fmt.Fprintln(w, "Code:      ", e.CodeName())
 	if e.Message == nil {
 		fmt.Fprintln(w, "Message:   ", e.Code.DefaultMessage())
 	} else {
@@ -325,6 +460,165 @@ func Explain(w io.Writer, err error, program string) {
 	if e.GotParam != nil {
 		fmt.Fprintln(w, "Got param: ", e.GotParam)
 	}
+	if e.Hint != nil {
+		fmt.Fprintln(w, "Hint:      ", *e.Hint)
+	}
+	for i, frame := range e.Trace {
+		if i == 0 {
+			fmt.Fprintln(w, "Trace:     ", traceLine(frame))
+		} else {
+			fmt.Fprintln(w, "           ", traceLine(frame))
+		}
+	}
+}
+
+// traceLine describes a call trace frame for humans.
+func traceLine(frame states.Frame) string {
+	line := fmt.Sprintf("called from %s at %s", frame.Name, frame.Pos)
+	if frame.Count > 1 {
+		line += fmt.Sprintf(" (%d times)", frame.Count)
+	}
+	return line
+}
+
+// ExplainJSON is like Explain, but writes the error as a single line of JSON,
+// for consumption by other tools. The JSON object has the following
+// properties, all of which except kind and message are omitted if not
+// applicable:
+//
+//	kind       error kind (Syntax, Type, Value, or Unknown)
+//	code       error code, e.g., NoSuchFuncer
+//	message    error message
+//	file       name of the file containing the program
+//	line       line number, starting at 1
+//	column     column number, starting at 1
+//	offset     byte offset, starting at 0
+//	endLine    line number of the end of the offending code
+//	endColumn  column number right after the offending code
+//	endOffset  byte offset right after the offending code
+//	wantType   expected type
+//	gotType    actual type
+//	gotValue   actual value, as a Bach literal
+//	inputType  input type
+//	name       funcer name
+//	argNum     argument number
+//	numParams  number of parameters
+//	paramNum   parameter number
+//	wantParam  expected parameter
+//	gotParam   actual parameter
+//	hint       a hint on how to fix the error
+//	exitCode   custom exit status
+//	trace      calls during which the error occurred, innermost first, as
+//	           objects with the properties name, file, line, column, offset,
+//	           and count
+func ExplainJSON(w io.Writer, err error) {
+	j := jsonError{}
+	e, ok := err.(*E)
+	if !ok {
+		j.Kind = UnknownKind.String()
+		j.Message = err.Error()
+	} else {
+		if e.Kind == nil {
+			j.Kind = UnknownKind.String()
+		} else {
+			j.Kind = e.Kind.String()
+		}
+		j.Code = e.CodeName()
+		if e.Code != nil {
+			j.Message = e.Code.DefaultMessage()
+		}
+		if e.Message != nil {
+			j.Message = *e.Message
+		}
+		if e.Pos != nil && e.Pos.Line > 0 {
+			j.File = e.Pos.Filename
+			j.Line = &e.Pos.Line
+			j.Column = &e.Pos.Column
+			j.Offset = &e.Pos.Offset
+			if e.EndPos != nil {
+				j.EndLine = &e.EndPos.Line
+				j.EndColumn = &e.EndPos.Column
+				j.EndOffset = &e.EndPos.Offset
+			}
+		}
+		if e.WantType != nil {
+			j.WantType = e.WantType.String()
+		}
+		if e.GotType != nil {
+			j.GotType = e.GotType.String()
+		}
+		if e.GotValue != nil {
+			j.GotValue, _ = e.GotValue.Repr()
+		}
+		if e.InputType != nil {
+			j.InputType = e.InputType.String()
+		}
+		if e.Name != nil {
+			j.Name = *e.Name
+		}
+		j.ArgNum = e.ArgNum
+		j.NumParams = e.NumParams
+		j.ParamNum = e.ParamNum
+		if e.WantParam != nil {
+			j.WantParam = e.WantParam.String()
+		}
+		if e.GotParam != nil {
+			j.GotParam = e.GotParam.String()
+		}
+		if e.Hint != nil {
+			j.Hint = *e.Hint
+		}
+		j.ExitCode = e.ExitCode
+		for _, frame := range e.Trace {
+			j.Trace = append(j.Trace, jsonFrame{
+				Name:   frame.Name,
+				File:   frame.Pos.Filename,
+				Line:   frame.Pos.Line,
+				Column: frame.Pos.Column,
+				Offset: frame.Pos.Offset,
+				Count:  frame.Count,
+			})
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	// like Explain, ignore write errors: there is nowhere left to report them
+	encoder.Encode(j)
+}
+
+type jsonError struct {
+	Kind      string      `json:"kind"`
+	Code      string      `json:"code,omitempty"`
+	Message   string      `json:"message"`
+	File      string      `json:"file,omitempty"`
+	Line      *int        `json:"line,omitempty"`
+	Column    *int        `json:"column,omitempty"`
+	Offset    *int        `json:"offset,omitempty"`
+	EndLine   *int        `json:"endLine,omitempty"`
+	EndColumn *int        `json:"endColumn,omitempty"`
+	EndOffset *int        `json:"endOffset,omitempty"`
+	WantType  string      `json:"wantType,omitempty"`
+	GotType   string      `json:"gotType,omitempty"`
+	GotValue  string      `json:"gotValue,omitempty"`
+	InputType string      `json:"inputType,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	ArgNum    *int        `json:"argNum,omitempty"`
+	NumParams *int        `json:"numParams,omitempty"`
+	ParamNum  *int        `json:"paramNum,omitempty"`
+	WantParam string      `json:"wantParam,omitempty"`
+	GotParam  string      `json:"gotParam,omitempty"`
+	Hint      string      `json:"hint,omitempty"`
+	ExitCode  *int        `json:"exitCode,omitempty"`
+	Trace     []jsonFrame `json:"trace,omitempty"`
+}
+
+type jsonFrame struct {
+	Name   string `json:"name"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+	Count  int    `json:"count"`
 }
 
 // Match compares its two error arguments. It can be used to check for expected
@@ -347,12 +641,18 @@ func Match(err1, err2 error) bool {
 	if e1.Kind != nil && *e2.Kind != *e1.Kind {
 		return false
 	}
-	if e1.Code != nil && *e2.Code != *e1.Code {
+	if e1.Code != nil && (e2.Code == nil || *e2.Code != *e1.Code) {
+		return false
+	}
+	if e1.UserCode != nil && (e2.UserCode == nil || *e2.UserCode != *e1.UserCode) {
 		return false
 	}
 	if e1.Pos != nil && *e2.Pos != *e1.Pos {
 		return false
 	}
+	if e1.EndPos != nil && (e2.EndPos == nil || *e2.EndPos != *e1.EndPos) {
+		return false
+	}
 	if e1.Message != nil && *e2.Message != *e1.Message {
 		return false
 	}
@@ -388,5 +688,28 @@ func Match(err1, err2 error) bool {
 	if e1.GotParam != nil && !e1.GotParam.Equivalent(e2.GotParam) {
 		return false
 	}
+	if e1.Hint != nil && (e2.Hint == nil || *e2.Hint != *e1.Hint) {
+		return false
+	}
+	if e1.ExitCode != nil && (e2.ExitCode == nil || *e2.ExitCode != *e1.ExitCode) {
+		return false
+	}
 	return true
 }
+
+// ExitStatus returns the exit status with which the Bach CLI terminates when
+// it encounters the given error. This is the error's exit code if it has one
+// between 1 and 255, and otherwise depends on the error's kind.
+func ExitStatus(err error) int {
+	e, ok := err.(*E)
+	if !ok {
+		return UnknownKind.ExitCode()
+	}
+	if e.ExitCode != nil && *e.ExitCode >= 1 && *e.ExitCode <= 255 {
+		return *e.ExitCode
+	}
+	if e.Kind == nil {
+		return UnknownKind.ExitCode()
+	}
+	return e.Kind.ExitCode()
+}
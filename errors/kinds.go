@@ -26,6 +26,21 @@ func (kind ErrorKind) String() string {
 	}
 }
 
+// ExitCode returns the exit status with which the Bach CLI terminates when it
+// encounters an error of this kind.
+func (kind ErrorKind) ExitCode() int {
+	switch kind {
+	case ValueKind:
+		return 1
+	case SyntaxKind:
+		return 2
+	case TypeKind:
+		return 3
+	default:
+		return 4
+	}
+}
+
 func ParseKind(s string) (ErrorKind, error) {
 	switch s {
 	case "Syntax":
@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLineWidth is the maximum number of characters of a source line that
+// Explain shows. Longer lines are cut down to a window around the error.
+const maxLineWidth = 80
+
+// contextLines is the number of lines that Explain shows before and after the
+// offending code if the program was read from a file.
+const contextLines = 2
+
+// explainSource writes the lines of source that e refers to, with the
+// offending code underlined. Lines are numbered if the program was read from a
+// file or the offending code spans several lines. For programs read from
+// files, a few surrounding lines are shown as well.
+func explainSource(w io.Writer, e *E, source string) {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	startLine, startColumn := e.Pos.Line, e.Pos.Column
+	if startLine > len(lines) {
+		return
+	}
+	if startColumn < 1 {
+		startColumn = 1
+	}
+	endLine, endColumn := startLine, startColumn+1
+	if e.EndPos != nil && (e.EndPos.Line > startLine || e.EndPos.Line == startLine && e.EndPos.Column > startColumn) {
+		endLine, endColumn = e.EndPos.Line, e.EndPos.Column
+	}
+	if endLine > len(lines) {
+		endLine, endColumn = len(lines), len([]rune(lines[len(lines)-1]))+1
+	}
+	// a span that ends at the start of a line ends at the end of the
+	// previous one
+	if endLine > startLine && endColumn == 1 {
+		endLine--
+		endColumn = len([]rune(lines[endLine-1])) + 1
+	}
+	// lines to show
+	first, last := startLine, endLine
+	if e.Pos.Filename != "" {
+		first -= contextLines
+		if first < 1 {
+			first = 1
+		}
+		last += contextLines
+		if last > len(lines) {
+			last = len(lines)
+		}
+		// don't show the empty "line" after a final newline
+		if last > endLine && last == len(lines) && lines[last-1] == "" {
+			last--
+		}
+	}
+	// window for long lines
+	width := 0
+	for i := first; i <= last; i++ {
+		if n := len([]rune(lines[i-1])); n > width {
+			width = n
+		}
+	}
+	offset := 0
+	if width > maxLineWidth {
+		offset = startColumn - 1 - maxLineWidth/4
+		if offset > width-maxLineWidth {
+			offset = width - maxLineWidth
+		}
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	// print
+	numbered := e.Pos.Filename != "" || endLine > startLine
+	gutterWidth := len(strconv.Itoa(last))
+	for i := first; i <= last; i++ {
+		line := []rune(lines[i-1])
+		text := sourceWindow(line, offset)
+		if numbered {
+			fmt.Fprintf(w, "%*d |", gutterWidth, i)
+			if text != "" {
+				fmt.Fprint(w, " ")
+			}
+		}
+		fmt.Fprintln(w, text)
+		if i < startLine || i > endLine {
+			continue
+		}
+		from := startColumn
+		if i > startLine {
+			from = 1
+			for from <= len(line) && (line[from-1] == ' ' || line[from-1] == '\t') {
+				from++
+			}
+		}
+		to := len(line) + 1
+		if i == endLine {
+			to = endColumn
+		}
+		if i > startLine && from >= to {
+			// nothing to underline on this line
+			continue
+		}
+		if numbered {
+			fmt.Fprintf(w, "%*s | ", gutterWidth, "")
+		}
+		fmt.Fprintln(w, underline(line, offset, from, to, i == startLine))
+	}
+}
+
+// sourceWindow returns the part of line that starts offset characters in and
+// is at most maxLineWidth characters long, marking cut-off parts with
+// ellipses.
+func sourceWindow(line []rune, offset int) string {
+	var b strings.Builder
+	if offset > 0 {
+		b.WriteString("…")
+		if offset < len(line) {
+			line = line[offset:]
+		} else {
+			line = nil
+		}
+	}
+	if len(line) > maxLineWidth {
+		b.WriteString(string(line[:maxLineWidth]))
+		b.WriteString("…")
+	} else {
+		b.WriteString(string(line))
+	}
+	return b.String()
+}
+
+// underline returns a line that, printed below the output of sourceWindow for
+// the same line and offset, underlines the columns from (inclusive) to to
+// (exclusive). If start is true, the first column is marked with a caret,
+// indicating the start of the offending code.
+func underline(line []rune, offset int, from int, to int, start bool) string {
+	var b strings.Builder
+	if offset > 0 {
+		// make room for the ellipsis
+		b.WriteString(" ")
+	}
+	if from <= offset {
+		from = offset + 1
+		start = false
+	}
+	if to > offset+maxLineWidth+1 {
+		to = offset + maxLineWidth + 1
+	}
+	if to <= from {
+		to = from + 1
+	}
+	for c := offset + 1; c < from; c++ {
+		// keep tabs so the underline lines up with the source
+		if c <= len(line) && line[c-1] == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	for c := from; c < to; c++ {
+		if c == from && start {
+			b.WriteRune('^')
+		} else {
+			b.WriteRune('~')
+		}
+	}
+	return b.String()
+}
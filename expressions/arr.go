@@ -11,6 +11,7 @@ import (
 
 type ArrExpression struct {
 	Pos      lexer.Position
+	EndPos   lexer.Position
 	Elements []Expression
 	RestPos  lexer.Position
 	Rest     Expression
@@ -20,12 +21,16 @@ func (x ArrExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x ArrExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x ArrExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	// make sure we got no params
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos))
+			errors.Span(x.Pos, x.EndPos))
 
 	}
 	// typecheck rest
@@ -48,7 +53,7 @@ func (x ArrExpression) Typecheck(inputShape shapes.Shape, params []*params.Param
 		if !(types.AnyArr).Subsumes(restShape.Type) {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.RestRequiresArrType),
-				errors.Pos(x.RestPos),
+				errors.Span(x.RestPos, x.Rest.EndPosition()),
 				errors.WantType(types.AnyArr),
 				errors.GotType(restShape.Type))
 		}
@@ -11,6 +11,7 @@ import (
 
 type AssignmentExpression struct {
 	Pos     lexer.Position
+	EndPos  lexer.Position
 	Pattern Pattern
 }
 
@@ -18,12 +19,16 @@ func (x AssignmentExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x AssignmentExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x AssignmentExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	// make sure we got no parameters
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos))
+			errors.Span(x.Pos, x.EndPos))
 
 	}
 	// typecheck pattern
@@ -35,7 +40,7 @@ func (x AssignmentExpression) Typecheck(inputShape shapes.Shape, params []*param
 	if !(types.Void{}).Subsumes(restType) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.NonExhaustiveMatch),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	// make action
@@ -1,6 +1,12 @@
 package expressions
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/params"
@@ -10,29 +16,41 @@ import (
 )
 
 type CallExpression struct {
-	Pos  lexer.Position
-	Name string
-	Args []Expression
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Name   string
+	Args   []Expression
 }
 
 func (x CallExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x CallExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x CallExpression) Typecheck(inputShape shapes.Shape, p []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	// go down the function stack and find the function invoked by this
 	// call
 	stack := inputShape.Stack
+	// the error for the first funcer whose parameters do not match the
+	// arguments, reported if no other funcer matches
+	var argErr error
 funcers:
 	for {
 		// reached bottom of stack without finding a matching funcer
 		if stack == nil {
+			if argErr != nil {
+				return shapes.Shape{}, nil, nil, argErr
+			}
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.NoSuchFuncer),
-				errors.Pos(x.Pos),
+				errors.Span(x.Pos, x.EndPos),
 				errors.InputType(inputShape.Type),
 				errors.Name(x.Name),
 				errors.NumParams(len(x.Args)+len(p)),
+				errors.Hint(noSuchFuncerHint(inputShape, x.Name, len(x.Args)+len(p))),
 			)
 		}
 		// try the funcer on top of the stack
@@ -76,29 +94,28 @@ funcers:
 			if err != nil {
 				return argOutputShape, argAction, argIDs, err
 			}
-			if !funcerDefinition.Params[i].OutputType.Bind(argOutputShape.Type, bindings) {
-				return shapes.Shape{}, nil, nil, errors.TypeError(
-					errors.Code(errors.ArgHasWrongOutputType),
-					errors.Pos(x.Pos),
-					errors.ArgNum(i+1),
-					errors.WantType(funcerDefinition.Params[i].OutputType.Instantiate(bindings)),
-					errors.GotType(argOutputShape.Type),
-				)
-			}
-			if !funcerDefinition.Params[i].OutputType.Instantiate(bindings).Subsumes(argOutputShape.Type) {
-				return shapes.Shape{}, nil, nil, errors.TypeError(
-					errors.Code(errors.ArgHasWrongOutputType),
-					errors.Pos(x.Pos),
-					errors.ArgNum(i+1),
-					errors.WantType(funcerDefinition.Params[i].OutputType.Instantiate(bindings)),
-					errors.GotType(argOutputShape.Type),
-				)
+			// on mismatch, try the remaining funcers, e.g., fatal
+			// with a message rather than an exit code
+			if !funcerDefinition.Params[i].OutputType.Bind(argOutputShape.Type, bindings) ||
+				!funcerDefinition.Params[i].OutputType.Instantiate(bindings).Subsumes(argOutputShape.Type) {
+				if argErr == nil {
+					argErr = errors.TypeError(
+						errors.Code(errors.ArgHasWrongOutputType),
+						errors.Span(x.Pos, x.EndPos),
+						errors.ArgNum(i+1),
+						errors.WantType(funcerDefinition.Params[i].OutputType.Instantiate(bindings)),
+						errors.GotType(argOutputShape.Type),
+					)
+				}
+				stack = stack.Tail
+				continue funcers
 			}
 			argActions[i] = argAction
 			argIDss[i] = argIDs
 			ids = ids.AddAll(argIDs)
 		}
-		// pass input variable stack to arguments
+		// pass input variable stack to arguments, recording calls of
+		// callbacks
 		action2 := func(inputState states.State, args []states.Action) states.State {
 			args2 := make([]states.Action, len(argActions)+len(args))
 			for i := range argActions {
@@ -106,7 +123,11 @@ funcers:
 				i := i
 				args2[i] = func(argInputState states.State, argArgs []states.Action) states.State {
 					argInputState.Stack = prunedStack
-					return argActions[i](argInputState, argArgs)
+					outputState := argActions[i](argInputState, argArgs)
+					if funcerDefinition.Params[i].Callback {
+						outputState.Thunk = states.ThunkInCall(outputState.Thunk, x.Name, x.Pos)
+					}
+					return outputState
 				}
 			}
 			for i := 0; i < len(args); i++ {
@@ -120,7 +141,7 @@ funcers:
 			if !gotParam.Subsumes(wantParam) {
 				return shapes.Shape{}, nil, nil, errors.TypeError(
 					errors.Code(errors.ParamDoesNotMatch),
-					errors.Pos(x.Pos),
+					errors.Span(x.Pos, x.EndPos),
 					errors.ParamNum(i+1),
 					errors.WantParam(gotParam),
 					errors.GotParam(wantParam),
@@ -157,3 +178,162 @@ func instantiate(pars []*params.Param, bindings map[string]types.Type) []*params
 	}
 	return result
 }
+
+// noSuchFuncerHint looks for near misses when no funcer matches a call:
+// funcers with the same name but a different input type or number of
+// parameters, and funcers with similar names. It returns a hint describing
+// them, or the empty string if there are none.
+func noSuchFuncerHint(inputShape shapes.Shape, name string, numParams int) string {
+	var inputTypes []string
+	var paramCounts []int
+	distances := make(map[string]int)
+	for stack := inputShape.Stack; stack != nil; stack = stack.Tail {
+		funcer := stack.Head
+		if funcer.Name == name {
+			if len(funcer.Params) == numParams {
+				inputTypes = appendNew(inputTypes, funcer.InputType.String())
+			} else if !containsInt(paramCounts, len(funcer.Params)) {
+				paramCounts = append(paramCounts, len(funcer.Params))
+			}
+			continue
+		}
+		if _, ok := distances[funcer.Name]; ok {
+			continue
+		}
+		// don't suggest operators for names or vice versa
+		if isName(funcer.Name) != isName(name) {
+			continue
+		}
+		distances[funcer.Name] = editDistance(strings.ToLower(name), strings.ToLower(funcer.Name))
+	}
+	if len(inputTypes) > 0 {
+		return fmt.Sprintf("`%s` with %s exists for input type %s, but the input has type `%s`.", name, countParams(numParams), enumerate(quote(inputTypes), "or"), inputShape.Type)
+	}
+	if len(paramCounts) > 0 {
+		sort.Ints(paramCounts)
+		counts := make([]string, len(paramCounts))
+		for i, count := range paramCounts {
+			counts[i] = fmt.Sprint(count)
+		}
+		noun := "parameters"
+		if len(paramCounts) == 1 && paramCounts[0] == 1 {
+			noun = "parameter"
+		}
+		return fmt.Sprintf("`%s` exists with %s %s, but is called with %s here.", name, enumerate(counts, "or"), noun, countParams(numParams))
+	}
+	// the longer the name, the more typos we tolerate
+	maxDistance := 2
+	if n := len([]rune(name)); n <= 2 {
+		maxDistance = 0
+	} else if n <= 5 {
+		maxDistance = 1
+	}
+	var similar []string
+	for other, distance := range distances {
+		if distance <= maxDistance {
+			similar = append(similar, other)
+		}
+	}
+	if len(similar) == 0 {
+		return ""
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if distances[similar[i]] != distances[similar[j]] {
+			return distances[similar[i]] < distances[similar[j]]
+		}
+		return similar[i] < similar[j]
+	})
+	if len(similar) > 3 {
+		similar = similar[:3]
+	}
+	return fmt.Sprintf("Did you mean %s?", enumerate(quote(similar), "or"))
+}
+
+func isName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func countParams(n int) string {
+	switch n {
+	case 0:
+		return "no parameters"
+	case 1:
+		return "1 parameter"
+	default:
+		return fmt.Sprintf("%d parameters", n)
+	}
+}
+
+func appendNew(strs []string, str string) []string {
+	for _, s := range strs {
+		if s == str {
+			return strs
+		}
+	}
+	return append(strs, str)
+}
+
+func containsInt(ints []int, i int) bool {
+	for _, j := range ints {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
+
+func quote(strs []string) []string {
+	quoted := make([]string, len(strs))
+	for i, str := range strs {
+		quoted[i] = "`" + str + "`"
+	}
+	return quoted
+}
+
+// enumerate joins strings as in "a", "a or b", "a, b, or c".
+func enumerate(strs []string, conjunction string) string {
+	switch len(strs) {
+	case 1:
+		return strs[0]
+	case 2:
+		return strs[0] + " " + conjunction + " " + strs[1]
+	default:
+		return strings.Join(strs[:len(strs)-1], ", ") + ", " + conjunction + " " + strs[len(strs)-1]
+	}
+}
+
+// editDistance computes the optimal string alignment distance between two
+// strings, i.e., the number of character insertions, deletions,
+// substitutions, and transpositions of adjacent characters needed to turn one
+// into the other.
+func editDistance(a string, b string) int {
+	r, s := []rune(a), []rune(b)
+	d := make([][]int, len(r)+1)
+	for i := range d {
+		d[i] = make([]int, len(s)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(r); i++ {
+		for j := 1; j <= len(s); j++ {
+			cost := 1
+			if r[i-1] == s[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && r[i-1] == s[j-2] && r[i-2] == s[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(r)][len(s)]
+}
@@ -10,20 +10,25 @@ import (
 )
 
 type CompositionExpression struct {
-	Pos   lexer.Position
-	Left  Expression
-	Right Expression
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Left   Expression
+	Right  Expression
 }
 
 func (x CompositionExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x CompositionExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x CompositionExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos))
+			errors.Span(x.Pos, x.EndPos))
 
 	}
 	middleShape, lAction, ids, err := x.Left.Typecheck(inputShape, nil)
@@ -33,7 +38,7 @@ func (x CompositionExpression) Typecheck(inputShape shapes.Shape, params []*para
 	if (types.Void{}).Subsumes(middleShape.Type) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ComposeWithVoid),
-			errors.Pos(x.Right.Position()))
+			errors.Span(x.Right.Position(), x.Right.EndPosition()))
 
 	}
 	outputShape, rAction, rIDs, err := x.Right.Typecheck(middleShape, nil)
@@ -52,5 +57,10 @@ func Compose(pos lexer.Position, l Expression, r Expression) Expression {
 	if l == nil {
 		return r
 	}
-	return &CompositionExpression{pos, l, r}
+	return &CompositionExpression{
+		Pos:    pos,
+		EndPos: r.EndPosition(),
+		Left:   l,
+		Right:  r,
+	}
 }
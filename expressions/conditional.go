@@ -11,6 +11,7 @@ import (
 
 type ConditionalExpression struct {
 	Pos                           lexer.Position
+	EndPos                        lexer.Position
 	Pattern                       Pattern
 	Guard                         Expression
 	Consequent                    Expression
@@ -25,12 +26,16 @@ func (x ConditionalExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x ConditionalExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	// make sure we got no parameters
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	// typecheck pattern
@@ -53,7 +58,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 		if !(types.Bool{}).Subsumes(guardOutputShape.Type) {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.ConditionMustBeBool),
-				errors.Pos(x.Guard.Position()),
+				errors.Span(x.Guard.Position(), x.Guard.EndPosition()),
 				errors.WantType(types.Bool{}),
 				errors.GotType(guardOutputShape.Type),
 			)
@@ -90,7 +95,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 		if (types.Void{}).Subsumes(inputShape.Type) {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.UnreachableElisClause),
-				errors.Pos(x.Pattern.Position()),
+				errors.Span(x.Pattern.Position(), x.Pattern.EndPosition()),
 			)
 		}
 		// typecheck pattern
@@ -111,7 +116,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 			if !(types.Bool{}).Subsumes(guardOutputShape.Type) {
 				return shapes.Shape{}, nil, nil, errors.TypeError(
 					errors.Code(errors.ConditionMustBeBool),
-					errors.Pos(x.AlternativeGuards[i].Position()),
+					errors.Span(x.AlternativeGuards[i].Position(), x.AlternativeGuards[i].EndPosition()),
 					errors.WantType(types.Bool{}),
 					errors.GotType(guardOutputShape.Type),
 				)
@@ -149,7 +154,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 		//if !(types.VoidType{}).Subsumes(inputShape.Type) {
 		//	return shapes.Shape{}, nil, nil, errors.TypeError(
 		//		errors.Code(errors.NonExhaustiveMatch),
-		//		errors.Pos(x.Pos),
+		//		errors.Span(x.Pos, x.EndPos),
 		//		errors.WantType(types.VoidType{}),
 		//		errors.GotType(inputShape.Type),
 		//	)
@@ -159,7 +164,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 		if !x.UnreachableAlternativeAllowed && (types.Void{}).Subsumes(inputShape.Type) {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.UnreachableElseClause),
-				errors.Pos(x.Alternative.Position()),
+				errors.Span(x.Alternative.Position(), x.Alternative.EndPosition()),
 			)
 		}
 		// alternative
@@ -230,7 +235,7 @@ func (x ConditionalExpression) Typecheck(inputShape shapes.Shape, params []*para
 				return inputState.Replace(states.ThunkFromError(err))
 			}
 			return inputState.Replace(states.ThunkFromError(errors.TypeError(
-				errors.Pos(x.Pos),
+				errors.Span(x.Pos, x.EndPos),
 				errors.Code(errors.UnexpectedValue),
 				errors.GotValue(val),
 			)))
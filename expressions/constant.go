@@ -10,20 +10,25 @@ import (
 )
 
 type ConstantExpression struct {
-	Pos   lexer.Position
-	Type  types.Type
-	Value states.Value
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Type   types.Type
+	Value  states.Value
 }
 
 func (x ConstantExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x ConstantExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x ConstantExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	outputShape := shapes.Shape{
@@ -11,6 +11,7 @@ import (
 
 type DefinitionExpression struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	InputType  types.Type
 	Name       string
 	Params     []*params.Param // these have to be pointers because we use them as IDs
@@ -23,12 +24,16 @@ func (x DefinitionExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x DefinitionExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x DefinitionExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	// make sure we got no parameters
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	// variables for body input stack, action (will be set at runtime)
@@ -51,7 +56,7 @@ func (x DefinitionExpression) Typecheck(inputShape shapes.Shape, params []*param
 			Stack:     bodyInputStack,
 			TypeStack: inputState.TypeStack,
 		}
-		return bodyAction(bodyInputState, nil).Thunk
+		return states.ThunkInCall(bodyAction(bodyInputState, nil).Thunk, x.Name, pos)
 	}
 	// make a funcer for the defined function, add it to the function stack
 	funFuncer := shapes.Funcer{InputType: x.InputType, Name: x.Name, Params: x.Params, OutputType: x.OutputType, Kernel: funKernel, IDs: nil}
@@ -90,7 +95,7 @@ func (x DefinitionExpression) Typecheck(inputShape shapes.Shape, params []*param
 	if !x.OutputType.Subsumes(bodyOutputShape.Type) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.FunctionBodyHasWrongOutputType),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 			errors.WantType(x.OutputType),
 			errors.GotType(bodyOutputShape.Type),
 		)
@@ -9,5 +9,6 @@ import (
 
 type Expression interface {
 	Position() lexer.Position
+	EndPosition() lexer.Position
 	Typecheck(inputShape shapes.Shape, params []*params.Param) (outputShape shapes.Shape, action states.Action, IDs *states.IDStack, err error)
 }
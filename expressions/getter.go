@@ -12,14 +12,19 @@ import (
 )
 
 type GetterExpression struct {
-	Pos  lexer.Position
-	Name string
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Name   string
 }
 
 func (x GetterExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x GetterExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x GetterExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	switch t := inputShape.Type.(type) {
 	case types.Obj:
@@ -32,7 +37,7 @@ func (x GetterExpression) Typecheck(inputShape shapes.Shape, params []*params.Pa
 		if !wantType.Subsumes(inputShape.Type) {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.NoSuchProperty),
-				errors.Pos(x.Pos),
+				errors.Span(x.Pos, x.EndPos),
 				errors.WantType(wantType),
 				errors.GotType(inputShape.Type),
 			)
@@ -55,7 +60,7 @@ func (x GetterExpression) Typecheck(inputShape shapes.Shape, params []*params.Pa
 		if err != nil || index < 0 {
 			return shapes.Shape{}, nil, nil, errors.TypeError(
 				errors.Code(errors.BadIndex),
-				errors.Pos(x.Pos),
+				errors.Span(x.Pos, x.EndPos),
 			)
 		}
 		var outputType types.Type
@@ -63,7 +68,7 @@ func (x GetterExpression) Typecheck(inputShape shapes.Shape, params []*params.Pa
 		for i := 0; i < index; i++ {
 			if types.VoidArr.Subsumes(restType.Tail) {
 				return shapes.Shape{}, nil, nil, errors.TypeError(
-					errors.Pos(x.Pos),
+					errors.Span(x.Pos, x.EndPos),
 					errors.Code(errors.NoSuchIndex),
 				)
 			}
@@ -92,7 +97,7 @@ func (x GetterExpression) Typecheck(inputShape shapes.Shape, params []*params.Pa
 	default:
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.NoGetterAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 			errors.GotType(inputShape.Type),
 		)
 	}
@@ -9,18 +9,23 @@ import (
 )
 
 type IdentityExpression struct {
-	Pos lexer.Position
+	Pos    lexer.Position
+	EndPos lexer.Position
 }
 
 func (x IdentityExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x IdentityExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x IdentityExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	return inputShape, func(inputState states.State, args []states.Action) states.State {
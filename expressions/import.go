@@ -0,0 +1,86 @@
+package expressions
+
+import (
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/params"
+	"github.com/texttheater/bach/shapes"
+	"github.com/texttheater/bach/states"
+	"github.com/texttheater/bach/types"
+)
+
+// ImportExpression runs the program in another file for the funcers and
+// variables it defines, and makes them available to the rest of the
+// importing program. The input is passed through unchanged. The output of the
+// imported program is ignored, but it is evaluated before the rest of the
+// importing program, so its side effects happen and its errors are reported.
+type ImportExpression struct {
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Path   string
+	Body   Expression
+	// Rest is the rest of the composition following the import, or nil.
+	Rest Expression
+}
+
+func (x ImportExpression) Position() lexer.Position {
+	return x.Pos
+}
+
+func (x ImportExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
+func (x ImportExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
+	if len(params) > 0 {
+		return shapes.Shape{}, nil, nil, errors.TypeError(
+			errors.Code(errors.ParamsNotAllowed),
+			errors.Span(x.Pos, x.EndPos),
+		)
+	}
+	// the imported program gets null as input, like a main program
+	bodyInputShape := shapes.Shape{
+		Type:  types.Null{},
+		Stack: inputShape.Stack,
+	}
+	bodyOutputShape, bodyAction, ids, err := x.Body.Typecheck(bodyInputShape, nil)
+	if err != nil {
+		return shapes.Shape{}, nil, nil, err
+	}
+	outputShape := shapes.Shape{
+		Type:  inputShape.Type,
+		Stack: bodyOutputShape.Stack,
+	}
+	var restAction states.Action
+	if x.Rest != nil {
+		var restIDs *states.IDStack
+		outputShape, restAction, restIDs, err = x.Rest.Typecheck(outputShape, nil)
+		if err != nil {
+			return shapes.Shape{}, nil, nil, err
+		}
+		ids = ids.AddAll(restIDs)
+	}
+	action := func(inputState states.State, args []states.Action) states.State {
+		bodyInputState := inputState.Replace(states.ThunkFromValue(states.NullValue{}))
+		bodyOutputState := bodyAction(bodyInputState, nil)
+		outputState := states.State{
+			Thunk:     inputState.Thunk,
+			Stack:     bodyOutputState.Stack,
+			TypeStack: inputState.TypeStack,
+		}
+		if restAction != nil {
+			outputState = restAction(outputState, nil)
+		}
+		// run the imported program before the rest
+		outputThunk := outputState.Thunk
+		outputState.Thunk = states.ThunkFromFunc(func() *states.Thunk {
+			_, err := bodyOutputState.Thunk.Eval()
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return outputThunk
+		})
+		return outputState
+	}
+	return outputShape, action, ids, nil
+}
@@ -11,6 +11,7 @@ import (
 
 type ObjExpression struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	PropValMap map[string]Expression
 }
 
@@ -18,11 +19,15 @@ func (x ObjExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x ObjExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x ObjExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	propTypeMap := make(map[string]types.Type)
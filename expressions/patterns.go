@@ -12,6 +12,7 @@ import (
 
 type Pattern interface {
 	Position() lexer.Position
+	EndPosition() lexer.Position
 	Typecheck(inputShape shapes.Shape) (outputShape shapes.Shape, restType types.Type, matcher Matcher, err error)
 }
 
@@ -19,6 +20,7 @@ type Matcher func(states.State) (*states.VariableStack, bool, error)
 
 type ArrPattern struct {
 	Pos             lexer.Position
+	EndPos          lexer.Position
 	ElementPatterns []Pattern
 	RestPattern     Pattern
 }
@@ -27,6 +29,10 @@ func (p ArrPattern) Position() lexer.Position {
 	return p.Pos
 }
 
+func (p ArrPattern) EndPosition() lexer.Position {
+	return p.EndPos
+}
+
 // spreadInputType spreads the input type for an array pattern over its
 // elements and rest.
 func spreadInputType(inputType types.Type, elementTypes []types.Type) (restType types.Type, ok bool) {
@@ -86,7 +92,7 @@ func (p ArrPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type
 	if !ok {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ImpossibleMatch),
-			errors.Pos(p.Pos),
+			errors.Span(p.Pos, p.EndPos),
 		)
 	}
 	// process element patterns
@@ -121,7 +127,7 @@ func (p ArrPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type
 	if (types.Void{}).Subsumes(intersection) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ImpossibleMatch),
-			errors.Pos(p.Pos),
+			errors.Span(p.Pos, p.EndPos),
 			errors.WantType(inputShape.Type),
 			errors.GotType(pType),
 		)
@@ -177,6 +183,7 @@ func (p ArrPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type
 
 type ObjPattern struct {
 	Pos            lexer.Position
+	EndPos         lexer.Position
 	PropPatternMap map[string]Pattern
 }
 
@@ -184,6 +191,10 @@ func (p ObjPattern) Position() lexer.Position {
 	return p.Pos
 }
 
+func (p ObjPattern) EndPosition() lexer.Position {
+	return p.EndPos
+}
+
 func (p ObjPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type, Matcher, error) {
 	// compute value input types
 	propInputTypeMap := make(map[string]types.Type)
@@ -250,7 +261,7 @@ func (p ObjPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type
 	if (types.Void{}).Subsumes(intersection) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ImpossibleMatch),
-			errors.Pos(p.Pos),
+			errors.Span(p.Pos, p.EndPos),
 			errors.WantType(inputShape.Type),
 			errors.GotType(pType),
 		)
@@ -294,22 +305,27 @@ func (p ObjPattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type
 }
 
 type TypePattern struct {
-	Pos  lexer.Position
-	Type types.Type
-	Name *string
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Type   types.Type
+	Name   *string
 }
 
 func (p TypePattern) Position() lexer.Position {
 	return p.Pos
 }
 
+func (p TypePattern) EndPosition() lexer.Position {
+	return p.EndPos
+}
+
 func (p TypePattern) Typecheck(inputShape shapes.Shape) (shapes.Shape, types.Type, Matcher, error) {
 	// partition the input type and check for impossible match
 	intersection, complement := inputShape.Type.Partition(p.Type)
 	if (types.Void{}).Subsumes(intersection) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ImpossibleMatch),
-			errors.Pos(p.Pos),
+			errors.Span(p.Pos, p.EndPos),
 			errors.WantType(inputShape.Type),
 			errors.GotType(p.Type),
 		)
@@ -14,6 +14,7 @@ import (
 
 type RegexpExpression struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Regexp *regexp.Regexp
 }
 
@@ -21,17 +22,21 @@ func (x RegexpExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x RegexpExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x RegexpExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	if !(types.Str{}).Subsumes(inputShape.Type) {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.RegexpWantsString),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 			errors.WantType(types.Str{}),
 			errors.GotType(inputShape.Type),
 		)
@@ -13,6 +13,7 @@ import (
 
 type TemplateLiteralExpression struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Pieces []Expression
 }
 
@@ -20,11 +21,15 @@ func (x TemplateLiteralExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x TemplateLiteralExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x *TemplateLiteralExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	outputShape := shapes.Shape{
@@ -10,19 +10,24 @@ import (
 )
 
 type WrapExpression struct {
-	Pos  lexer.Position
-	Prop string
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Prop   string
 }
 
 func (x WrapExpression) Position() lexer.Position {
 	return x.Pos
 }
 
+func (x WrapExpression) EndPosition() lexer.Position {
+	return x.EndPos
+}
+
 func (x WrapExpression) Typecheck(inputShape shapes.Shape, params []*params.Param) (shapes.Shape, states.Action, *states.IDStack, error) {
 	if len(params) > 0 {
 		return shapes.Shape{}, nil, nil, errors.TypeError(
 			errors.Code(errors.ParamsNotAllowed),
-			errors.Pos(x.Pos),
+			errors.Span(x.Pos, x.EndPos),
 		)
 	}
 	shape := shapes.Shape{
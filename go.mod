@@ -3,10 +3,12 @@ module github.com/texttheater/bach
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/alecthomas/kong v1.2.1
 	github.com/alecthomas/participle v0.7.1
 	github.com/c-bata/go-prompt v0.2.6
 	github.com/texttheater/golang-variadic-hypot v0.0.0-20191220105357-fe88ff4bc7a6
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
@@ -17,5 +19,4 @@ require (
 	github.com/pkg/term v1.2.0-beta.2 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	golang.org/x/sys v0.0.0-20220610221304-9f5ed59c137d // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -6,14 +6,16 @@ import (
 )
 
 type ArrLiteral struct {
-	Pos  lexer.Position  `"["`
-	Rest *ArrLiteralRest `@@`
+	Pos    lexer.Position `"["`
+	EndPos lexer.Position
+	Rest   *ArrLiteralRest `@@`
 }
 
-func (g *ArrLiteral) Ast() (expressions.Expression, error) {
-	ast, err := g.Rest.Ast()
+func (g *ArrLiteral) Ast(ctx *parseContext) (expressions.Expression, error) {
+	ast, err := g.Rest.Ast(ctx)
 	if ast != nil {
 		ast.Pos = g.Pos
+		ast.EndPos = ctx.endPos(g.EndPos)
 	}
 	return ast, err
 }
@@ -24,24 +26,24 @@ type ArrLiteralRest struct {
 	Rest     *Composition   `  ( ";" @@ )? )? "]"`
 }
 
-func (g *ArrLiteralRest) Ast() (*expressions.ArrExpression, error) {
+func (g *ArrLiteralRest) Ast(ctx *parseContext) (*expressions.ArrExpression, error) {
 	var elements []expressions.Expression
 	var rest expressions.Expression
 	if g.Element != nil {
 		elements = make([]expressions.Expression, 1+len(g.Elements))
 		var err error
-		elements[0], err = g.Element.Ast()
+		elements[0], err = g.Element.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for i, element := range g.Elements {
-			elements[i+1], err = element.Ast()
+			elements[i+1], err = element.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
 		}
 		if g.Rest != nil {
-			rest, err = g.Rest.Ast()
+			rest, err = g.Rest.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
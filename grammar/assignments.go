@@ -11,46 +11,55 @@ import (
 
 type Assignment struct {
 	Pos            lexer.Position
+	EndPos         lexer.Position
 	NameAssignment *NameAssignment `  @@`
 	ArrAssignment  *ArrAssignment  `| @@`
 	ObjAssignment  *ObjAssignment  `| @@`
 }
 
-func (g *Assignment) Ast() (expressions.Expression, error) {
+func (g *Assignment) Ast(ctx *parseContext) (expressions.Expression, error) {
 	if g.NameAssignment != nil {
-		return g.NameAssignment.Ast()
+		return g.NameAssignment.Ast(ctx)
 	}
 	if g.ArrAssignment != nil {
-		return g.ArrAssignment.Ast()
+		return g.ArrAssignment.Ast(ctx)
 	}
 	if g.ObjAssignment != nil {
-		return g.ObjAssignment.Ast()
+		return g.ObjAssignment.Ast(ctx)
 	}
 	panic("invalid assignment")
 }
 
 type NameAssignment struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	EqName string `@EqName`
 }
 
-func (g *NameAssignment) Ast() (expressions.Expression, error) {
+func (g *NameAssignment) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.EqName[1:]
-	pattern := expressions.TypePattern{g.Pos, types.Any{}, &name}
+	pattern := expressions.TypePattern{
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Type:   types.Any{},
+		Name:   &name,
+	}
 	return &expressions.AssignmentExpression{
 		Pos:     g.Pos,
+		EndPos:  ctx.endPos(g.EndPos),
 		Pattern: pattern,
 	}, nil
 }
 
 type ArrAssignment struct {
 	Pos      lexer.Position `"=["`
-	Element  *Pattern       `( @@`
-	Elements []*Pattern     `  ( "," @@ )*`
-	Rest     *Pattern       `  ( ";" @@ )? )? "]"`
+	EndPos   lexer.Position
+	Element  *Pattern   `( @@`
+	Elements []*Pattern `  ( "," @@ )*`
+	Rest     *Pattern   `  ( ";" @@ )? )? "]"`
 }
 
-func (g *ArrAssignment) Ast() (expressions.Expression, error) {
+func (g *ArrAssignment) Ast(ctx *parseContext) (expressions.Expression, error) {
 	numEls := 0
 	if g.Element != nil {
 		numEls = 1
@@ -58,14 +67,14 @@ func (g *ArrAssignment) Ast() (expressions.Expression, error) {
 	}
 	elPatterns := make([]expressions.Pattern, numEls)
 	if g.Element != nil {
-		pattern, err := g.Element.Ast()
+		pattern, err := g.Element.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		elPatterns[0] = pattern
 	}
 	for i, element := range g.Elements {
-		pattern, err := element.Ast()
+		pattern, err := element.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -79,15 +88,17 @@ func (g *ArrAssignment) Ast() (expressions.Expression, error) {
 		}
 	} else {
 		var err error
-		restPattern, err = g.Rest.Ast()
+		restPattern, err = g.Rest.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 	}
 	return &expressions.AssignmentExpression{
-		Pos: g.Pos,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
 		Pattern: expressions.ArrPattern{
 			Pos:             g.Pos,
+			EndPos:          ctx.endPos(g.EndPos),
 			ElementPatterns: elPatterns,
 			RestPattern:     restPattern,
 		},
@@ -96,22 +107,23 @@ func (g *ArrAssignment) Ast() (expressions.Expression, error) {
 
 type ObjAssignment struct {
 	Pos    lexer.Position `"={"`
-	Prop   *string        `( ( @Lid | @Op1 | @Op2 | @NumLiteral )`
-	Value  *Pattern       `  ":" @@`
-	Props  []string       `   ( "," ( @Lid | @Op1 | @Op2 | @NumLiteral )`
-	Values []*Pattern     `     ":" @@ )* )? "}"`
+	EndPos lexer.Position
+	Prop   *string    `( ( @Lid | @Op1 | @Op2 | @NumLiteral )`
+	Value  *Pattern   `  ":" @@`
+	Props  []string   `   ( "," ( @Lid | @Op1 | @Op2 | @NumLiteral )`
+	Values []*Pattern `     ":" @@ )* )? "}"`
 }
 
-func (g *ObjAssignment) Ast() (expressions.Expression, error) {
+func (g *ObjAssignment) Ast(ctx *parseContext) (expressions.Expression, error) {
 	propPatternMap := make(map[string]expressions.Pattern)
 	if g.Prop != nil {
-		p, err := g.Value.Ast()
+		p, err := g.Value.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		propPatternMap[*g.Prop] = p
 		for i, prop := range g.Props {
-			p, err := g.Values[i].Ast()
+			p, err := g.Values[i].Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -119,9 +131,11 @@ func (g *ObjAssignment) Ast() (expressions.Expression, error) {
 		}
 	}
 	return &expressions.AssignmentExpression{
-		Pos: g.Pos,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
 		Pattern: expressions.ObjPattern{
 			Pos:            g.Pos,
+			EndPos:         ctx.endPos(g.EndPos),
 			PropPatternMap: propPatternMap,
 		},
 	}, nil
@@ -14,6 +14,7 @@ import (
 
 type Call struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	Op1Num      *Op1Num      `  @@`
 	Op2Num      *Op2Num      `| @@`
 	Op1Lid      *Op1Lid      `| @@`
@@ -26,39 +27,40 @@ type Call struct {
 	Name        *string      `| ( @Lid | @Op1 | @Op2 )`
 }
 
-func (g *Call) Ast() (expressions.Expression, error) {
+func (g *Call) Ast(ctx *parseContext) (expressions.Expression, error) {
 	if g.Op1Num != nil {
-		return g.Op1Num.Ast()
+		return g.Op1Num.Ast(ctx)
 	}
 	if g.Op2Num != nil {
-		return g.Op2Num.Ast()
+		return g.Op2Num.Ast(ctx)
 	}
 	if g.Op1Lid != nil {
-		return g.Op1Lid.Ast()
+		return g.Op1Lid.Ast(ctx)
 	}
 	if g.Op2Lid != nil {
-		return g.Op2Lid.Ast()
+		return g.Op2Lid.Ast(ctx)
 	}
 	if g.NameString != nil {
-		return g.NameString.Ast()
+		return g.NameString.Ast(ctx)
 	}
 	if g.NameRegexp != nil {
-		return g.NameRegexp.Ast()
+		return g.NameRegexp.Ast(ctx)
 	}
 	if g.NameArr != nil {
-		return g.NameArr.Ast()
+		return g.NameArr.Ast(ctx)
 	}
 	if g.NameObj != nil {
-		return g.NameObj.Ast()
+		return g.NameObj.Ast(ctx)
 	}
 	if g.NameArglist != nil {
-		return g.NameArglist.Ast()
+		return g.NameArglist.Ast(ctx)
 	}
 	if g.Name != nil {
 		return &expressions.CallExpression{
-			Pos:  g.Pos,
-			Name: *g.Name,
-			Args: nil,
+			Pos:    g.Pos,
+			EndPos: ctx.endPos(g.EndPos),
+			Name:   *g.Name,
+			Args:   nil,
 		}, nil
 	}
 	panic("invalid call")
@@ -66,10 +68,11 @@ func (g *Call) Ast() (expressions.Expression, error) {
 
 type Op1Num struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Op1Num string `@Op1Num`
 }
 
-func (g *Op1Num) Ast() (expressions.Expression, error) {
+func (g *Op1Num) Ast(ctx *parseContext) (expressions.Expression, error) {
 	op := g.Op1Num[:1]
 	num, err := strconv.ParseFloat(g.Op1Num[1:], 64)
 	if err != nil {
@@ -78,13 +81,15 @@ func (g *Op1Num) Ast() (expressions.Expression, error) {
 	numPos := g.Pos
 	numPos.Column += 1
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: op,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   op,
 		Args: []expressions.Expression{
 			&expressions.ConstantExpression{
-				Pos:   numPos,
-				Type:  types.Num{},
-				Value: states.NumValue(num),
+				Pos:    numPos,
+				EndPos: ctx.endPos(g.EndPos),
+				Type:   types.Num{},
+				Value:  states.NumValue(num),
 			},
 		},
 	}, nil
@@ -92,10 +97,11 @@ func (g *Op1Num) Ast() (expressions.Expression, error) {
 
 type Op2Num struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Op2Num string `@Op2Num`
 }
 
-func (g *Op2Num) Ast() (expressions.Expression, error) {
+func (g *Op2Num) Ast(ctx *parseContext) (expressions.Expression, error) {
 	op := g.Op2Num[:2]
 	num, err := strconv.ParseFloat(g.Op2Num[2:], 64)
 	if err != nil {
@@ -104,13 +110,15 @@ func (g *Op2Num) Ast() (expressions.Expression, error) {
 	numPos := g.Pos
 	numPos.Column += 2
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: op,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   op,
 		Args: []expressions.Expression{
 			&expressions.ConstantExpression{
-				Pos:   numPos,
-				Type:  types.Num{},
-				Value: states.NumValue(num),
+				Pos:    numPos,
+				EndPos: ctx.endPos(g.EndPos),
+				Type:   types.Num{},
+				Value:  states.NumValue(num),
 			},
 		},
 	}, nil
@@ -118,22 +126,25 @@ func (g *Op2Num) Ast() (expressions.Expression, error) {
 
 type Op1Lid struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Op1Lid string `@LangleULid | @Op1Lid`
 }
 
-func (g *Op1Lid) Ast() (expressions.Expression, error) {
+func (g *Op1Lid) Ast(ctx *parseContext) (expressions.Expression, error) {
 	op := g.Op1Lid[:1]
 	name := g.Op1Lid[1:]
 	namePos := g.Pos
 	namePos.Column += 1
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: op,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   op,
 		Args: []expressions.Expression{
 			&expressions.CallExpression{
-				Pos:  namePos,
-				Name: name,
-				Args: nil,
+				Pos:    namePos,
+				EndPos: ctx.endPos(g.EndPos),
+				Name:   name,
+				Args:   nil,
 			},
 		},
 	}, nil
@@ -141,22 +152,25 @@ func (g *Op1Lid) Ast() (expressions.Expression, error) {
 
 type Op2Lid struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Op2Lid string `@Op2Lid`
 }
 
-func (g *Op2Lid) Ast() (expressions.Expression, error) {
+func (g *Op2Lid) Ast(ctx *parseContext) (expressions.Expression, error) {
 	op := g.Op2Lid[:2]
 	name := g.Op2Lid[2:]
 	namePos := g.Pos
 	namePos.Column += 2
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: op,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   op,
 		Args: []expressions.Expression{
 			&expressions.CallExpression{
-				Pos:  namePos,
-				Name: name,
-				Args: nil,
+				Pos:    namePos,
+				EndPos: ctx.endPos(g.EndPos),
+				Name:   name,
+				Args:   nil,
 			},
 		},
 	}, nil
@@ -164,10 +178,11 @@ func (g *Op2Lid) Ast() (expressions.Expression, error) {
 
 type NameRegexp struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	NameRegexp string `@NameRegexp`
 }
 
-func (g *NameRegexp) Ast() (expressions.Expression, error) {
+func (g *NameRegexp) Ast(ctx *parseContext) (expressions.Expression, error) {
 	i := strings.Index(g.NameRegexp, "~")
 	name := g.NameRegexp[:i]
 	regexpString := g.NameRegexp[i+1 : len(g.NameRegexp)-1]
@@ -177,16 +192,18 @@ func (g *NameRegexp) Ast() (expressions.Expression, error) {
 	if err != nil {
 		return nil, errors.SyntaxError(
 			errors.Code(errors.BadRegexp),
-			errors.Pos(regexpPos),
+			errors.Span(regexpPos, ctx.endPos(g.EndPos)),
 			errors.Message(err.Error()),
 		)
 	}
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
 		Args: []expressions.Expression{
 			&expressions.RegexpExpression{
 				Pos:    regexpPos,
+				EndPos: ctx.endPos(g.EndPos),
 				Regexp: regexp,
 			},
 		},
@@ -195,83 +212,92 @@ func (g *NameRegexp) Ast() (expressions.Expression, error) {
 
 type NameArr struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	NameLbrack string          `@NameLbrack`
 	Rest       *ArrLiteralRest `@@`
 }
 
-func (g *NameArr) Ast() (expressions.Expression, error) {
+func (g *NameArr) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameLbrack[:len(g.NameLbrack)-1]
-	argAst, err := g.Rest.Ast()
+	argAst, err := g.Rest.Ast(ctx)
 	if err != nil {
 		return nil, err
 	}
 	argAst.Pos = g.Pos // FIXME increase column by 1
+	argAst.EndPos = ctx.endPos(g.EndPos)
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
-		Args: []expressions.Expression{argAst},
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
+		Args:   []expressions.Expression{argAst},
 	}, nil
 }
 
 type NameObj struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	NameLbrace string          `@NameLbrace`
 	Rest       *ObjLiteralRest `@@`
 }
 
-func (g *NameObj) Ast() (expressions.Expression, error) {
+func (g *NameObj) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameLbrace[:len(g.NameLbrace)-1]
-	argAst, err := g.Rest.Ast()
+	argAst, err := g.Rest.Ast(ctx)
 	if err != nil {
 		return nil, err
 	}
 	argAst.Pos = g.Pos // FIXME increase column by 1
+	argAst.EndPos = ctx.endPos(g.EndPos)
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
-		Args: []expressions.Expression{argAst},
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
+		Args:   []expressions.Expression{argAst},
 	}, nil
 }
 
 type NameArglist struct {
 	Pos      lexer.Position
+	EndPos   lexer.Position
 	NameLpar string         `@NameLpar`
 	Arg      *Composition   `@@`
 	Args     []*Composition `( "," @@ )* ")"`
 }
 
-func (g *NameArglist) Ast() (expressions.Expression, error) {
+func (g *NameArglist) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameLpar[:len(g.NameLpar)-1]
 	args := make([]expressions.Expression, len(g.Args)+1)
 	var err error
-	args[0], err = g.Arg.Ast()
+	args[0], err = g.Arg.Ast(ctx)
 	if err != nil {
 		return nil, err
 	}
 	for i, arg := range g.Args {
-		args[i+1], err = arg.Ast()
+		args[i+1], err = arg.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 	}
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
-		Args: args,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
+		Args:   args,
 	}, nil
 }
 
 type NameString struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	NameQuot  string      `@NameQuot`
 	Fragments []*Fragment `@@* "\""`
 }
 
-func (g *NameString) Ast() (expressions.Expression, error) {
+func (g *NameString) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameQuot[:len(g.NameQuot)-1]
 	pieces := make([]expressions.Expression, len(g.Fragments))
 	for i, fragment := range g.Fragments {
-		piece, err := fragment.Ast()
+		piece, err := fragment.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -280,11 +306,13 @@ func (g *NameString) Ast() (expressions.Expression, error) {
 	strPos := g.Pos
 	strPos.Column += len(name)
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
 		Args: []expressions.Expression{
 			&expressions.TemplateLiteralExpression{
 				Pos:    strPos,
+				EndPos: ctx.endPos(g.EndPos),
 				Pieces: pieces,
 			},
 		},
@@ -293,12 +321,13 @@ func (g *NameString) Ast() (expressions.Expression, error) {
 
 type NameArray struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	NameLbrack string         `@NameLbrack`
 	Element    *Composition   `( @@`
 	Elements   []*Composition `  ( "," @@ )* )? "]"`
 }
 
-func (g *NameArray) Ast() (expressions.Expression, error) {
+func (g *NameArray) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameLbrack[:len(g.NameLbrack)-1]
 	arrPos := g.Pos
 	arrPos.Column += len(name)
@@ -306,23 +335,25 @@ func (g *NameArray) Ast() (expressions.Expression, error) {
 	if g.Element != nil {
 		elements = make([]expressions.Expression, 1+len(g.Elements))
 		var err error
-		elements[0], err = g.Element.Ast()
+		elements[0], err = g.Element.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for i, element := range g.Elements {
-			elements[i+1], err = element.Ast()
+			elements[i+1], err = element.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
 		}
 	}
 	return &expressions.CallExpression{
-		Pos:  g.Pos,
-		Name: name,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
 		Args: []expressions.Expression{
 			&expressions.ArrExpression{
 				Pos:      arrPos,
+				EndPos:   ctx.endPos(g.EndPos),
 				Elements: elements,
 			},
 		},
@@ -331,6 +362,7 @@ func (g *NameArray) Ast() (expressions.Expression, error) {
 
 type NameObject struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	NameLbrace string         `@NameLbarace`
 	Prop       *string        `( ( @Lid | @Op1 | @Op2 | @NumLiteral )`
 	Value      *Composition   `  ":" @@`
@@ -338,19 +370,19 @@ type NameObject struct {
 	Values     []*Composition `    ":" @@ )* )? "}"`
 }
 
-func (g *NameObject) Ast() (expressions.Expression, error) {
+func (g *NameObject) Ast(ctx *parseContext) (expressions.Expression, error) {
 	name := g.NameLbrace[:len(g.NameLbrace)-1]
 	objPos := g.Pos
 	objPos.Column += len(name)
 	propValMap := make(map[string]expressions.Expression)
 	if g.Prop != nil {
 		var err error
-		propValMap[*g.Prop], err = g.Value.Ast()
+		propValMap[*g.Prop], err = g.Value.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for i := range g.Props {
-			propValMap[g.Props[i]], err = g.Values[i].Ast()
+			propValMap[g.Props[i]], err = g.Values[i].Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -360,8 +392,9 @@ func (g *NameObject) Ast() (expressions.Expression, error) {
 		Name: name,
 		Args: []expressions.Expression{
 			&expressions.ObjExpression{
-				objPos,
-				propValMap,
+				Pos:        objPos,
+				EndPos:     ctx.endPos(g.EndPos),
+				PropValMap: propValMap,
 			},
 		},
 	}, nil
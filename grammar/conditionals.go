@@ -8,6 +8,7 @@ import (
 
 type Conditional struct {
 	Pos              lexer.Position
+	EndPos           lexer.Position
 	Pattern          *Pattern           `( "is" @@`
 	Guard            *Composition       `  ( "with" @@)?`
 	Condition        *Composition       `| "if" @@ )`
@@ -19,6 +20,7 @@ type Conditional struct {
 
 type Alternative struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	Pattern    *Pattern     `( "elis" @@`
 	Guard      *Composition `  ( "with" @@ )?`
 	Condition  *Composition `| "elif" @@ )`
@@ -27,12 +29,13 @@ type Alternative struct {
 
 type PredAlternative struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	Pattern   *Pattern     `( "elis" @@`
 	Guard     *Composition `  ( "with" @@ )?`
 	Condition *Composition `| "elif" @@ )`
 }
 
-func (g *Conditional) Ast() (expressions.Expression, error) {
+func (g *Conditional) Ast(ctx *parseContext) (expressions.Expression, error) {
 	var pattern expressions.Pattern
 	var guard expressions.Expression
 	var err error
@@ -42,17 +45,17 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 			Pos:  g.Pos,
 			Type: types.Any{},
 		}
-		guard, err = g.Condition.Ast()
+		guard, err = g.Condition.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		pattern, err = g.Pattern.Ast()
+		pattern, err = g.Pattern.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		if g.Guard != nil {
-			guard, err = g.Guard.Ast()
+			guard, err = g.Guard.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -80,17 +83,17 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 					Type: types.Any{},
 					Name: nil,
 				}
-				alternativeGuards[i], err = alternative.Condition.Ast()
+				alternativeGuards[i], err = alternative.Condition.Ast(ctx)
 				if err != nil {
 					return nil, err
 				}
 			} else {
-				alternativePatterns[i], err = alternative.Pattern.Ast()
+				alternativePatterns[i], err = alternative.Pattern.Ast(ctx)
 				if err != nil {
 					return nil, err
 				}
 				if alternative.Guard != nil {
-					alternativeGuards[i], err = alternative.Guard.Ast()
+					alternativeGuards[i], err = alternative.Guard.Ast(ctx)
 					if err != nil {
 						return nil, err
 					}
@@ -102,7 +105,7 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 		alternativePatterns = make([]expressions.Pattern, len(g.Alternatives))
 		alternativeGuards = make([]expressions.Expression, len(g.Alternatives))
 		alternativeConsequents = make([]expressions.Expression, len(g.Alternatives))
-		consequent, err = g.Consequent.Ast()
+		consequent, err = g.Consequent.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -114,23 +117,23 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 					Type: types.Any{},
 					Name: nil,
 				}
-				alternativeGuards[i], err = alternative.Condition.Ast()
+				alternativeGuards[i], err = alternative.Condition.Ast(ctx)
 				if err != nil {
 					return nil, err
 				}
 			} else {
-				alternativePatterns[i], err = alternative.Pattern.Ast()
+				alternativePatterns[i], err = alternative.Pattern.Ast(ctx)
 				if err != nil {
 					return nil, err
 				}
 				if alternative.Guard != nil {
-					alternativeGuards[i], err = alternative.Guard.Ast()
+					alternativeGuards[i], err = alternative.Guard.Ast(ctx)
 					if err != nil {
 						return nil, err
 					}
 				}
 			}
-			alternativeConsequents[i], err = alternative.Consequent.Ast()
+			alternativeConsequents[i], err = alternative.Consequent.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -145,7 +148,7 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 		}
 	} else {
 		if g.Alternative != nil {
-			alternative, err = g.Alternative.Ast()
+			alternative, err = g.Alternative.Ast(ctx)
 		}
 		if err != nil {
 			return nil, err
@@ -154,6 +157,7 @@ func (g *Conditional) Ast() (expressions.Expression, error) {
 	// return
 	return &expressions.ConditionalExpression{
 		Pos:                    g.Pos,
+		EndPos:                 ctx.endPos(g.EndPos),
 		Pattern:                pattern,
 		Guard:                  guard,
 		Consequent:             consequent,
@@ -8,6 +8,7 @@ import (
 
 type Definition struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	InputType  *TypeTemplate `"for" @@`
 	Name       *string       `"def" ( ( @Lid | @Op1 | @Op2 )`
 	NameLpar   *string       `      | @NameLpar`
@@ -17,7 +18,7 @@ type Definition struct {
 	Body       *Composition  `"as" @@ "ok"`
 }
 
-func (g *Definition) Ast() (expressions.Expression, error) {
+func (g *Definition) Ast(ctx *parseContext) (expressions.Expression, error) {
 	inputType := g.InputType.Ast()
 	var name string
 	var pars []*params.Param
@@ -43,12 +44,13 @@ func (g *Definition) Ast() (expressions.Expression, error) {
 		}
 	}
 	outputType := g.OutputType.Ast()
-	body, err := g.Body.Ast()
+	body, err := g.Body.Ast(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return &expressions.DefinitionExpression{
 		Pos:        g.Pos,
+		EndPos:     ctx.endPos(g.EndPos),
 		InputType:  inputType,
 		Name:       name,
 		Params:     pars,
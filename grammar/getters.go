@@ -9,6 +9,7 @@ import (
 
 type Getter struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	LidGetter *string `  @LidGetter`
 	Op1Getter *string `| @Op1Getter`
 	Op2Getter *string `| @Op2Getter`
@@ -16,7 +17,7 @@ type Getter struct {
 	StrGetter *string `| @StrGetter`
 }
 
-func (g *Getter) Ast() (expressions.Expression, error) {
+func (g *Getter) Ast(ctx *parseContext) (expressions.Expression, error) {
 	var name string
 	var err error
 	if g.LidGetter != nil {
@@ -47,7 +48,8 @@ func (g *Getter) Ast() (expressions.Expression, error) {
 		panic("invalid getter")
 	}
 	return &expressions.GetterExpression{
-		Pos:  g.Pos,
-		Name: name,
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Name:   name,
 	}, nil
 }
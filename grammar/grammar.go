@@ -9,28 +9,54 @@ import (
 
 type Composition struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	Component  *Component   `@@`
 	Components []*Component `( @@ )*`
 }
 
-func (g *Composition) Ast() (expressions.Expression, error) {
-	pos := g.Component.Pos
-	e, err := g.Component.Ast()
-	if err != nil {
-		return nil, err
-	}
-	for _, comp := range g.Components {
-		compAst, err := comp.Ast()
+func (g *Composition) Ast(ctx *parseContext) (expressions.Expression, error) {
+	return composeComponents(ctx, append([]*Component{g.Component}, g.Components...))
+}
+
+// composeComponents converts a non-empty sequence of components to an AST. The
+// components following an import become part of the import expression, so
+// that they are only evaluated after the imported program has run.
+func composeComponents(ctx *parseContext, comps []*Component) (expressions.Expression, error) {
+	var e expressions.Expression
+	for i, comp := range comps {
+		compAst, err := comp.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
-		e = &expressions.CompositionExpression{pos, e, compAst}
+		endPos := comp.EndPos
+		imp, isImport := compAst.(*expressions.ImportExpression)
+		if isImport && i+1 < len(comps) {
+			imp.Rest, err = composeComponents(ctx, comps[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			endPos = comps[len(comps)-1].EndPos
+		}
+		if e == nil {
+			e = compAst
+		} else {
+			e = &expressions.CompositionExpression{
+				Pos:    comps[0].Pos,
+				EndPos: ctx.endPos(endPos),
+				Left:   e,
+				Right:  compAst,
+			}
+		}
+		if imp != nil && imp.Rest != nil {
+			break
+		}
 	}
 	return e, nil
 }
 
 type Component struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	NumLiteral  *float64     `  @NumLiteral`
 	StrLiteral  *StrLiteral  `| @@`
 	ArrLiteral  *ArrLiteral  `| @@`
@@ -41,42 +67,54 @@ type Component struct {
 	Conditional *Conditional `| @@`
 	Regexp      *Regexp      `| @@`
 	Getter      *Getter      `| @@`
+	Import      *Import      `| @@`
 }
 
-func (g *Component) Ast() (expressions.Expression, error) {
+func (g *Component) Ast(ctx *parseContext) (expressions.Expression, error) {
 	if g.NumLiteral != nil {
 		return &expressions.ConstantExpression{
-			Pos:   g.Pos,
-			Type:  types.Num{},
-			Value: states.NumValue(*g.NumLiteral),
+			Pos:    g.Pos,
+			EndPos: ctx.endPos(g.EndPos),
+			Type:   types.Num{},
+			Value:  states.NumValue(*g.NumLiteral),
 		}, nil
 	}
 	if g.StrLiteral != nil {
-		return g.StrLiteral.Ast()
+		return g.StrLiteral.Ast(ctx)
 	}
 	if g.ArrLiteral != nil {
-		return g.ArrLiteral.Ast()
+		return g.ArrLiteral.Ast(ctx)
 	}
 	if g.ObjLiteral != nil {
-		return g.ObjLiteral.Ast()
+		return g.ObjLiteral.Ast(ctx)
 	}
 	if g.Call != nil {
-		return g.Call.Ast()
+		x, err := g.Call.Ast(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// record where the call ends, for value errors raised at
+		// its position
+		ctx.info.CallEnds[x.Position()] = x.EndPosition()
+		return x, nil
 	}
 	if g.Assignment != nil {
-		return g.Assignment.Ast()
+		return g.Assignment.Ast(ctx)
 	}
 	if g.Definition != nil {
-		return g.Definition.Ast()
+		return g.Definition.Ast(ctx)
 	}
 	if g.Conditional != nil {
-		return g.Conditional.Ast()
+		return g.Conditional.Ast(ctx)
 	}
 	if g.Regexp != nil {
-		return g.Regexp.Ast()
+		return g.Regexp.Ast(ctx)
 	}
 	if g.Getter != nil {
-		return g.Getter.Ast()
+		return g.Getter.Ast(ctx)
+	}
+	if g.Import != nil {
+		return g.Import.Ast(ctx)
 	}
 	panic("invalid component")
 }
@@ -0,0 +1,84 @@
+package grammar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/expressions"
+)
+
+type Import struct {
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Path   *StrLiteral `"import" @@`
+}
+
+func (g *Import) Ast(ctx *parseContext) (expressions.Expression, error) {
+	path, ok, err := g.Path.StaticStr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.SyntaxError(
+			errors.Code(errors.ImportFailed),
+			errors.Span(g.Pos, ctx.endPos(g.EndPos)),
+			errors.Message("The path to import must be a string literal without placeholders."),
+		)
+	}
+	if ctx.skipImports {
+		return &expressions.IdentityExpression{
+			Pos:    g.Pos,
+			EndPos: ctx.endPos(g.EndPos),
+		}, nil
+	}
+	// relative paths are relative to the importing file
+	filename := path
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(filepath.Dir(g.Pos.Filename), filename)
+	}
+	for i, importing := range ctx.importing {
+		if sameFile(importing, filename) {
+			cycle := append(append([]string{}, ctx.importing[i:]...), filename)
+			return nil, errors.SyntaxError(
+				errors.Code(errors.ImportCycle),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
+				errors.Message("import cycle: "+strings.Join(cycle, " imports ")),
+			)
+		}
+	}
+	program, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.SyntaxError(
+			errors.Code(errors.ImportFailed),
+			errors.Span(g.Pos, ctx.endPos(g.EndPos)),
+			errors.Message(err.Error()),
+		)
+	}
+	ctx.info.Sources[filename] = string(program)
+	body, err := ctx.parse(filename, string(program))
+	if err != nil {
+		return nil, err
+	}
+	return &expressions.ImportExpression{
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Path:   filename,
+		Body:   body,
+	}, nil
+}
+
+// sameFile reports whether two filenames refer to the same file.
+func sameFile(a string, b string) bool {
+	aAbs, err := filepath.Abs(a)
+	if err != nil {
+		return a == b
+	}
+	bAbs, err := filepath.Abs(b)
+	if err != nil {
+		return a == b
+	}
+	return aAbs == bAbs
+}
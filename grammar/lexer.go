@@ -9,9 +9,11 @@ var LexerDefinition lexer.Definition = lexer.Must(stateful.New(stateful.Rules{
 	"Root": {
 		// whitespace
 		{"whitespace", `\s+`, nil},
+		// comments
+		{"comment", `#[^\n]*`, nil},
 		// tokens starting type literals
 		{"TypeKeywordLangle", `(?:Arr|Obj)<`, nil},
-		{"TypeKeyword", `(?:Void|Null|Reader|Bool|Num|Str|Any)\b`, nil},
+		{"TypeKeyword", `(?:Void|Null|Reader|Writer|Bool|Num|Str|Any)\b`, nil},
 		// tokens starting calls
 		{"Op1Num", `[+\-*/%<>](?:\d+\.(?:\d+)?(?:[eE][+-]?\d+)?|\d+[eE][+-]?\d+|\.\d+(?:[eE][+-]?\d+)?|\d+)`, nil},
 		{"Op2Num", `(?:==|<=|>=|\*\*)(?:\d+\.(?:\d+)?(?:[eE][+-]?\d+)?|\d+[eE][+-]?\d+|\.\d+(?:[eE][+-]?\d+)?|\d+)`, nil},
@@ -28,7 +30,7 @@ var LexerDefinition lexer.Definition = lexer.Must(stateful.New(stateful.Rules{
 		{"EqLbrack", `=\[`, nil},
 		{"EqLbrace", `={`, stateful.Push("Braces")},
 		// keywords
-		{"Keyword", `(?:for|def|as|ok|if|then|elif|else|is|elis|with)\b`, nil},
+		{"Keyword", `(?:for|def|as|ok|if|then|elif|else|is|elis|with|import)\b`, nil},
 		// names
 		{"Lid", `[\p{Ll}_][\p{L}_0-9]*`, nil},
 		{"Op2", `==|<=|>=|\*\*`, nil},
@@ -7,14 +7,16 @@ import (
 )
 
 type ObjLiteral struct {
-	Pos  lexer.Position  `"{"`
-	Rest *ObjLiteralRest "@@"
+	Pos    lexer.Position `"{"`
+	EndPos lexer.Position
+	Rest   *ObjLiteralRest "@@"
 }
 
-func (g *ObjLiteral) Ast() (expressions.Expression, error) {
-	ast, rest := g.Rest.Ast()
+func (g *ObjLiteral) Ast(ctx *parseContext) (expressions.Expression, error) {
+	ast, rest := g.Rest.Ast(ctx)
 	if ast != nil {
 		ast.Pos = g.Pos
+		ast.EndPos = ctx.endPos(g.EndPos)
 	}
 	return ast, rest
 }
@@ -26,24 +28,24 @@ type ObjLiteralRest struct {
 	Values []*Composition `    ":" @@ )* )? "}"`
 }
 
-func (g *ObjLiteralRest) Ast() (*expressions.ObjExpression, error) {
+func (g *ObjLiteralRest) Ast(ctx *parseContext) (*expressions.ObjExpression, error) {
 	propValMap := make(map[string]expressions.Expression)
 	if g.Prop != nil {
 		var err error
-		prop, err := g.Prop.StaticStr()
+		prop, err := g.Prop.StaticStr(ctx)
 		if err != nil {
 			return nil, err
 		}
-		propValMap[prop], err = g.Value.Ast()
+		propValMap[prop], err = g.Value.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for i := range g.Props {
-			prop, err = g.Props[i].StaticStr()
+			prop, err = g.Props[i].StaticStr(ctx)
 			if err != nil {
 				return nil, err
 			}
-			propValMap[prop], err = g.Values[i].Ast()
+			propValMap[prop], err = g.Values[i].Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -56,20 +58,21 @@ func (g *ObjLiteralRest) Ast() (*expressions.ObjExpression, error) {
 
 type Prop struct {
 	Pos        lexer.Position
+	EndPos     lexer.Position
 	StrLiteral *StrLiteral `  @@`
 	Other      *string     `| @Lid | @Op1 | @Op2 | @NumLiteral`
 }
 
-func (g *Prop) StaticStr() (string, error) {
+func (g *Prop) StaticStr(ctx *parseContext) (string, error) {
 	if g.StrLiteral != nil {
-		str, ok, err := g.StrLiteral.StaticStr()
+		str, ok, err := g.StrLiteral.StaticStr(ctx)
 		if err != nil {
 			return "", err
 		}
 		if !ok {
 			return "", errors.SyntaxError(
 				errors.Code(errors.Syntax),
-				errors.Pos(g.Pos),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 				errors.Message("Can't use a dynamic string literal for object property."),
 			)
 		}
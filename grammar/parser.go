@@ -1,7 +1,10 @@
 package grammar
 
 import (
+	"strings"
+
 	"github.com/alecthomas/participle"
+	"github.com/alecthomas/participle/lexer"
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/expressions"
 	"github.com/texttheater/bach/params"
@@ -48,34 +51,199 @@ func init() {
 	}
 }
 
-func ParseComposition(input string) (expressions.Expression, error) {
+// SourceInfo holds what the parser records about a program and the files it
+// imports, for explaining errors.
+type SourceInfo struct {
+	// Sources maps the names of imported files to their contents.
+	Sources map[string]string
+	// CallEnds maps the positions of calls to the positions right after
+	// them.
+	CallEnds map[lexer.Position]lexer.Position
+}
+
+// ParseComposition parses a program and converts it to an AST. It also returns
+// information about the sources of the program and the files it imports.
+func ParseComposition(input string) (expressions.Expression, *SourceInfo, error) {
+	return ParseCompositionFile("", input)
+}
+
+// ParseCompositionFile is like ParseComposition, but records the given filename
+// in the positions of the resulting expressions and errors.
+func ParseCompositionFile(filename string, input string) (expressions.Expression, *SourceInfo, error) {
+	ctx := &parseContext{
+		info: &SourceInfo{
+			Sources:  make(map[string]string),
+			CallEnds: make(map[lexer.Position]lexer.Position),
+		},
+	}
+	x, err := ctx.parse(filename, input)
+	return x, ctx.info, err
+}
+
+// ParseCompositionWithoutImports is like ParseComposition, but does not read
+// imported files. Imports are replaced with expressions that pass their input
+// through, so the funcers and variables they define are missing from the
+// result. This is meant for analyzing programs on the fly, e.g., for tab
+// completion, where reading files is unwanted.
+func ParseCompositionWithoutImports(input string) (expressions.Expression, error) {
+	ctx := &parseContext{
+		info: &SourceInfo{
+			Sources:  make(map[string]string),
+			CallEnds: make(map[lexer.Position]lexer.Position),
+		},
+		skipImports: true,
+	}
+	return ctx.parse("", input)
+}
+
+// A parseContext holds the state of parsing a program together with the files
+// it imports.
+type parseContext struct {
+	// importing holds the names of the files currently being parsed,
+	// outermost first, for detecting import cycles.
+	importing []string
+	// info collects the sources of imported files and the ends of calls.
+	info *SourceInfo
+	// tokenEnds maps the offsets of the tokens of the file currently being
+	// converted to an AST to the positions right after the respective
+	// preceding tokens. Participle sets the EndPos fields of grammar nodes
+	// to the position of the next token, which may be preceded by
+	// whitespace and comments; endPos uses tokenEnds to find where the
+	// node really ends.
+	tokenEnds map[int]lexer.Position
+	// skipImports is true if imported files are not to be read.
+	skipImports bool
+}
+
+// parse parses one file, or the program if filename is empty, and converts
+// it to an AST.
+func (ctx *parseContext) parse(filename string, input string) (expressions.Expression, error) {
+	if filename != "" {
+		ctx.importing = append(ctx.importing, filename)
+		defer func() {
+			ctx.importing = ctx.importing[:len(ctx.importing)-1]
+		}()
+	}
+	lex, err := LexerDefinition.Lex(namedReader{strings.NewReader(input), filename})
+	if err != nil {
+		return nil, syntaxError(err)
+	}
+	peeker, err := lexer.Upgrade(lex)
+	if err != nil {
+		return nil, syntaxError(err)
+	}
+	defer func(saved map[int]lexer.Position) {
+		ctx.tokenEnds = saved
+	}(ctx.tokenEnds)
+	ctx.tokenEnds = findTokenEnds(peeker)
+	composition := &Composition{}
+	err = parser.ParseFromLexer(peeker, composition)
+	if err != nil {
+		return nil, syntaxError(err)
+	}
+	return composition.Ast(ctx)
+}
+
+// findTokenEnds returns the tokenEnds for the tokens that peeker has yet to
+// consume.
+func findTokenEnds(peeker *lexer.PeekingLexer) map[int]lexer.Position {
+	ends := make(map[int]lexer.Position, peeker.Length())
+	// the last token peeked at is EOF
+	for i := 1; i <= peeker.Length(); i++ {
+		previous, _ := peeker.Peek(i - 1)
+		token, _ := peeker.Peek(i)
+		end := previous.Pos
+		for _, r := range previous.Value {
+			if r == '\n' {
+				end.Line++
+				end.Column = 1
+			} else {
+				end.Column++
+			}
+		}
+		end.Offset += len(previous.Value)
+		ends[token.Pos.Offset] = end
+	}
+	return ends
+}
+
+// endPos returns the position where a grammar node really ends, given the
+// EndPos that participle set for it.
+func (ctx *parseContext) endPos(pos lexer.Position) lexer.Position {
+	end, ok := ctx.tokenEnds[pos.Offset]
+	if !ok {
+		return pos
+	}
+	end.Filename = pos.Filename
+	return end
+}
+
+// Incomplete reports whether input is not a valid program, but could become
+// one if more input were appended, e.g., because it ends inside an unclosed
+// conditional, definition, bracket or string literal.
+func Incomplete(input string) bool {
 	composition := &Composition{}
 	err := parser.ParseString(input, composition)
+	if err == nil {
+		return false
+	}
+	if parserError, ok := err.(participle.Error); ok && parserError.Token().EOF() {
+		return true
+	}
+	// The parser does not always get as far as the end of the input, e.g.,
+	// after a trailing comma in an array literal, so also check for
+	// unclosed brackets.
+	lex, err := LexerDefinition.Lex(strings.NewReader(input))
+	if err != nil {
+		return false
+	}
+	tokens, err := lexer.ConsumeAll(lex)
 	if err != nil {
-		if parserError, ok := err.(participle.Error); ok {
-			return nil, errors.SyntaxError(
-				errors.Code(errors.Syntax),
-				errors.Pos(parserError.Token().Pos),
-				errors.Message(parserError.Message()),
-			)
+		return false
+	}
+	symbols := LexerDefinition.Symbols()
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case symbols["Lpar"], symbols["Lbrack"], symbols["Lbrace"],
+			symbols["NameLpar"], symbols["NameLbrack"], symbols["NameLbrace"],
+			symbols["EqLbrack"], symbols["EqLbrace"]:
+			depth++
+		case symbols["Rpar"], symbols["Rbrack"], symbols["Rbrace"]:
+			depth--
 		}
-		return nil, err
 	}
-	return composition.Ast()
+	return depth > 0
+}
+
+// syntaxError converts errors from the parser into Bach syntax errors.
+func syntaxError(err error) error {
+	if parserError, ok := err.(participle.Error); ok {
+		return errors.SyntaxError(
+			errors.Code(errors.Syntax),
+			errors.Pos(parserError.Token().Pos),
+			errors.Message(parserError.Message()),
+		)
+	}
+	return err
+}
+
+// namedReader is a reader with a name, which the lexer uses as the filename
+// of positions.
+type namedReader struct {
+	*strings.Reader
+	name string
+}
+
+func (r namedReader) Name() string {
+	return r.name
 }
 
 func ParseType(input string) (types.Type, error) {
 	t := &Type{}
 	err := typeParser.ParseString(input, t)
 	if err != nil {
-		if parserError, ok := err.(participle.Error); ok {
-			return nil, errors.SyntaxError(
-				errors.Code(errors.Syntax),
-				errors.Pos(parserError.Token().Pos),
-				errors.Message(parserError.Message()),
-			)
-		}
-		return nil, err
+		return nil, syntaxError(err)
 	}
 	return t.Ast(), nil
 }
@@ -84,14 +252,7 @@ func ParseTypeTemplate(input string) (types.Type, error) {
 	t := &TypeTemplate{}
 	err := templateParser.ParseString(input, t)
 	if err != nil {
-		if parserError, ok := err.(participle.Error); ok {
-			return nil, errors.SyntaxError(
-				errors.Code(errors.Syntax),
-				errors.Pos(parserError.Token().Pos),
-				errors.Message(parserError.Message()),
-			)
-		}
-		return nil, err
+		return nil, syntaxError(err)
 	}
 	return t.Ast(), nil
 }
@@ -100,14 +261,7 @@ func ParseParam(input string) (*params.Param, error) {
 	p := &Param{}
 	err := paramParser.ParseString(input, p)
 	if err != nil {
-		if parserError, ok := err.(participle.Error); ok {
-			return nil, errors.SyntaxError(
-				errors.Code(errors.Syntax),
-				errors.Pos(parserError.Token().Pos),
-				errors.Message(parserError.Message()),
-			)
-		}
-		return nil, err
+		return nil, syntaxError(err)
 	}
 	return p.Ast()
 }
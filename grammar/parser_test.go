@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/alecthomas/participle"
+	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/grammar"
 )
 
@@ -42,6 +43,10 @@ func TestTypes(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	_, err = grammar.ParseType("Reader|Writer")
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestTypeTemplates(t *testing.T) {
@@ -61,3 +66,54 @@ func TestTypeTemplates(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCompositionFile(t *testing.T) {
+	x, _, err := grammar.ParseCompositionFile("test.bach", "1\n+2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Position().Filename != "test.bach" {
+		t.Fatal("filename not recorded in position")
+	}
+	_, _, err = grammar.ParseCompositionFile("test.bach", "1\n&")
+	e, ok := err.(*errors.E)
+	if !ok {
+		t.Fatal("expected syntax error")
+	}
+	if e.Pos.Filename != "test.bach" || e.Pos.Line != 2 {
+		t.Fatalf("unexpected error position %s", e.Pos)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	for _, input := range []string{
+		`1 if ==1 then 2`,
+		`for Num def f Num as`,
+		`[1, 2`,
+		`[1,`,
+		`for Any def f(x Num) Num as x ok 1 f(`,
+		`{a: 1`,
+		`"abc`,
+	} {
+		if !grammar.Incomplete(input) {
+			t.Errorf("%q should be incomplete", input)
+		}
+	}
+	for _, input := range []string{
+		`1 if ==1 then 2 else 3 ok`,
+		`1 )`,
+		`[1, 2] len)`,
+		`1 # comment`,
+	} {
+		if grammar.Incomplete(input) {
+			t.Errorf("%q should not be incomplete", input)
+		}
+	}
+}
+
+func TestCompositionWithoutImports(t *testing.T) {
+	_, err := grammar.ParseCompositionWithoutImports(`import "no/such/file.bach" 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,33 +8,34 @@ import (
 
 type Pattern struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	NamePattern *NamePattern `  @@`
 	TypePattern *TypePattern `| @@`
 	ArrPattern  *ArrPattern  `| @@`
 	ObjPattern  *ObjPattern  `| @@`
 }
 
-func (g *Pattern) Ast() (expressions.Pattern, error) {
+func (g *Pattern) Ast(ctx *parseContext) (expressions.Pattern, error) {
 	if g.NamePattern != nil {
-		p, err := g.NamePattern.Ast()
+		p, err := g.NamePattern.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		return p, nil
 	} else if g.TypePattern != nil {
-		p, err := g.TypePattern.Ast()
+		p, err := g.TypePattern.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		return p, nil
 	} else if g.ArrPattern != nil {
-		p, err := g.ArrPattern.Ast()
+		p, err := g.ArrPattern.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		return p, nil
 	} else if g.ObjPattern != nil {
-		p, err := g.ObjPattern.Ast()
+		p, err := g.ObjPattern.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -45,42 +46,55 @@ func (g *Pattern) Ast() (expressions.Pattern, error) {
 }
 
 type NamePattern struct {
-	Pos  lexer.Position
-	Name string `@Lid | @Op1 | @Op2`
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Name   string `@Lid | @Op1 | @Op2`
 }
 
-func (g *NamePattern) Ast() (expressions.Pattern, error) {
-	return expressions.TypePattern{g.Pos, types.Any{}, &g.Name}, nil
+func (g *NamePattern) Ast(ctx *parseContext) (expressions.Pattern, error) {
+	return expressions.TypePattern{
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Type:   types.Any{},
+		Name:   &g.Name,
+	}, nil
 }
 
 type TypePattern struct {
-	Pos  lexer.Position
-	Type *Type   `@@`
-	Name *string `( @Lid | @Op1 | @Op2 )?`
+	Pos    lexer.Position
+	EndPos lexer.Position
+	Type   *Type   `@@`
+	Name   *string `( @Lid | @Op1 | @Op2 )?`
 }
 
-func (g *TypePattern) Ast() (expressions.Pattern, error) {
-	return expressions.TypePattern{g.Pos, g.Type.Ast(), g.Name}, nil
+func (g *TypePattern) Ast(ctx *parseContext) (expressions.Pattern, error) {
+	return expressions.TypePattern{
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Type:   g.Type.Ast(),
+		Name:   g.Name,
+	}, nil
 }
 
 type ArrPattern struct {
 	Pos      lexer.Position `"["`
-	Element  *Pattern       `( @@`
-	Elements []*Pattern     `  ( "," @@ )*`
-	Rest     *Pattern       `  ( ";" @@ )? )? "]"`
+	EndPos   lexer.Position
+	Element  *Pattern   `( @@`
+	Elements []*Pattern `  ( "," @@ )*`
+	Rest     *Pattern   `  ( ";" @@ )? )? "]"`
 }
 
-func (g *ArrPattern) Ast() (expressions.Pattern, error) {
+func (g *ArrPattern) Ast(ctx *parseContext) (expressions.Pattern, error) {
 	var elPatterns []expressions.Pattern
 	if g.Element != nil {
 		elPatterns = make([]expressions.Pattern, len(g.Elements)+1)
-		p, err := g.Element.Ast()
+		p, err := g.Element.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		elPatterns[0] = p
 		for i, el := range g.Elements {
-			p, err = el.Ast()
+			p, err = el.Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -95,13 +109,14 @@ func (g *ArrPattern) Ast() (expressions.Pattern, error) {
 		}
 	} else {
 		var err error
-		restPattern, err = g.Rest.Ast()
+		restPattern, err = g.Rest.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 	}
 	return &expressions.ArrPattern{
 		Pos:             g.Pos,
+		EndPos:          ctx.endPos(g.EndPos),
 		ElementPatterns: elPatterns,
 		RestPattern:     restPattern,
 	}, nil
@@ -109,22 +124,23 @@ func (g *ArrPattern) Ast() (expressions.Pattern, error) {
 
 type ObjPattern struct {
 	Pos    lexer.Position `"{"`
-	Prop   *string        `( ( @Lid | @Op1 | @Op2 | @NumLiteral )`
-	Value  *Pattern       `  ":" @@`
-	Props  []string       `   ( "," ( @Lid | @Op1 | @Op2 | @NumLiteral )`
-	Values []*Pattern     `     ":" @@ )* )? "}"`
+	EndPos lexer.Position
+	Prop   *string    `( ( @Lid | @Op1 | @Op2 | @NumLiteral )`
+	Value  *Pattern   `  ":" @@`
+	Props  []string   `   ( "," ( @Lid | @Op1 | @Op2 | @NumLiteral )`
+	Values []*Pattern `     ":" @@ )* )? "}"`
 }
 
-func (g *ObjPattern) Ast() (expressions.Pattern, error) {
+func (g *ObjPattern) Ast(ctx *parseContext) (expressions.Pattern, error) {
 	propPatternMap := make(map[string]expressions.Pattern)
 	if g.Prop != nil {
-		p, err := g.Value.Ast()
+		p, err := g.Value.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
 		propPatternMap[*g.Prop] = p
 		for i, prop := range g.Props {
-			p, err := g.Values[i].Ast()
+			p, err := g.Values[i].Ast(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -133,6 +149,7 @@ func (g *ObjPattern) Ast() (expressions.Pattern, error) {
 	}
 	return &expressions.ObjPattern{
 		Pos:            g.Pos,
+		EndPos:         ctx.endPos(g.EndPos),
 		PropPatternMap: propPatternMap,
 	}, nil
 }
@@ -10,21 +10,23 @@ import (
 
 type Regexp struct {
 	Pos    lexer.Position
+	EndPos lexer.Position
 	Regexp string `@Regexp`
 }
 
-func (g *Regexp) Ast() (expressions.Expression, error) {
+func (g *Regexp) Ast(ctx *parseContext) (expressions.Expression, error) {
 	regexpString := g.Regexp[1 : len(g.Regexp)-1]
 	regexp, err := regexp.Compile(regexpString)
 	if err != nil {
 		return nil, errors.SyntaxError(
 			errors.Code(errors.BadRegexp),
-			errors.Pos(g.Pos),
+			errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 			errors.Message(err.Error()),
 		)
 	}
 	regexpExpression := &expressions.RegexpExpression{
 		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
 		Regexp: regexp,
 	}
 	return regexpExpression, nil
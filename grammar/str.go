@@ -12,13 +12,14 @@ import (
 
 type StrLiteral struct {
 	Pos       lexer.Position
+	EndPos    lexer.Position
 	Fragments []*Fragment `"\"" @@* "\""`
 }
 
-func (g *StrLiteral) Ast() (expressions.Expression, error) {
+func (g *StrLiteral) Ast(ctx *parseContext) (expressions.Expression, error) {
 	pieces := make([]expressions.Expression, len(g.Fragments))
 	for i, fragment := range g.Fragments {
-		piece, err := fragment.Ast()
+		piece, err := fragment.Ast(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -26,11 +27,12 @@ func (g *StrLiteral) Ast() (expressions.Expression, error) {
 	}
 	return &expressions.TemplateLiteralExpression{
 		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
 		Pieces: pieces,
 	}, nil
 }
 
-func (g *StrLiteral) StaticStr() (string, bool, error) {
+func (g *StrLiteral) StaticStr(ctx *parseContext) (string, bool, error) {
 	if len(g.Fragments) != 1 {
 		return "", false, nil
 	}
@@ -39,7 +41,7 @@ func (g *StrLiteral) StaticStr() (string, bool, error) {
 		if len(*fragment.Dbrace) == 1 {
 			return "", false, errors.SyntaxError(
 				errors.Code(errors.Syntax),
-				errors.Pos(g.Pos),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 				errors.Message("Use a double brace }} for a literal brace }"),
 			)
 		}
@@ -50,7 +52,7 @@ func (g *StrLiteral) StaticStr() (string, bool, error) {
 		if err != nil {
 			return "", false, errors.SyntaxError(
 				errors.Code(errors.Syntax),
-				errors.Pos(g.Pos),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 				errors.Message(err.Error()),
 			)
 		}
@@ -61,14 +63,15 @@ func (g *StrLiteral) StaticStr() (string, bool, error) {
 
 type Fragment struct {
 	Pos         lexer.Position
+	EndPos      lexer.Position
 	Composition *Composition `( "{" @@ "}"`
 	Dbrace      *string      `| @Dbrace`
 	Text        *string      `| @Char )`
 }
 
-func (g *Fragment) Ast() (expressions.Expression, error) {
+func (g *Fragment) Ast(ctx *parseContext) (expressions.Expression, error) {
 	if g.Composition != nil {
-		return g.Composition.Ast()
+		return g.Composition.Ast(ctx)
 	}
 	var str string
 	var err error
@@ -76,7 +79,7 @@ func (g *Fragment) Ast() (expressions.Expression, error) {
 		if len(*g.Dbrace) == 1 {
 			return nil, errors.SyntaxError(
 				errors.Code(errors.Syntax),
-				errors.Pos(g.Pos),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 				errors.Message("Use a double brace }} for a literal brace }"),
 			)
 		}
@@ -86,14 +89,15 @@ func (g *Fragment) Ast() (expressions.Expression, error) {
 		if err != nil {
 			return nil, errors.SyntaxError(
 				errors.Code(errors.Syntax),
-				errors.Pos(g.Pos),
+				errors.Span(g.Pos, ctx.endPos(g.EndPos)),
 				errors.Message(err.Error()),
 			)
 		}
 	}
 	return &expressions.ConstantExpression{
-		Pos:   g.Pos,
-		Type:  types.Str{},
-		Value: states.StrValue(str),
+		Pos:    g.Pos,
+		EndPos: ctx.endPos(g.EndPos),
+		Type:   types.Str{},
+		Value:  states.StrValue(str),
 	}, nil
 }
@@ -25,6 +25,7 @@ type NonDisjunctiveTypeTemplate struct {
 	VoidType             *VoidType             `  @@`
 	NullType             *NullType             `| @@`
 	ReaderType           *ReaderType           `| @@`
+	WriterType           *WriterType           `| @@`
 	BoolType             *BoolType             `| @@`
 	NumType              *NumType              `| @@`
 	StrType              *StrType              `| @@`
@@ -44,6 +45,9 @@ func (g *NonDisjunctiveTypeTemplate) Ast() types.Type {
 	if g.ReaderType != nil {
 		return g.ReaderType.Ast()
 	}
+	if g.WriterType != nil {
+		return g.WriterType.Ast()
+	}
 	if g.BoolType != nil {
 		return g.BoolType.Ast()
 	}
@@ -25,6 +25,7 @@ type NonDisjunctiveType struct {
 	VoidType     *VoidType     `  @@`
 	NullType     *NullType     `| @@`
 	ReaderType   *ReaderType   `| @@`
+	WriterType   *WriterType   `| @@`
 	BoolType     *BoolType     `| @@`
 	NumType      *NumType      `| @@`
 	StrType      *StrType      `| @@`
@@ -44,6 +45,9 @@ func (g *NonDisjunctiveType) Ast() types.Type {
 	if g.ReaderType != nil {
 		return g.ReaderType.Ast()
 	}
+	if g.WriterType != nil {
+		return g.WriterType.Ast()
+	}
 	if g.BoolType != nil {
 		return g.BoolType.Ast()
 	}
@@ -92,6 +96,14 @@ func (g *ReaderType) Ast() types.Type {
 	return types.Reader{}
 }
 
+type WriterType struct {
+	Pos lexer.Position `"Writer"`
+}
+
+func (g *WriterType) Ast() types.Type {
+	return types.Writer{}
+}
+
 type BoolType struct {
 	Pos lexer.Position `"Bool"`
 }
@@ -0,0 +1,68 @@
+package interpreter_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/interpreter"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := strconv.Quote(filepath.Join(dir, "file.txt"))
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`"a\nb" writeFile(%s) =_ %s readText`, file, file),
+		`Str`,
+		`"a\nb"`,
+		nil,
+		t,
+	)
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`["a", "b"] writeFile(%s) =_ ["c"] appendFile(%s) =_ %s readFile lines`, file, file, file),
+		`Arr<Str...>`,
+		`["a", "b", "c"]`,
+		nil,
+		t,
+	)
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`"" writeFile(%s) =_ %s listDir`, strconv.Quote(filepath.Join(dir, "file.bach")), strconv.Quote(dir)),
+		`Arr<Str...>`,
+		fmt.Sprintf(`[%s, %s]`, strconv.Quote(filepath.Join(dir, "file.bach")), file),
+		nil,
+		t,
+	)
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`%s glob`, strconv.Quote(filepath.Join(dir, "*.txt"))),
+		`Arr<Str...>`,
+		fmt.Sprintf(`[%s]`, file),
+		nil,
+		t,
+	)
+	// long lines
+	long := filepath.Join(dir, "long.tsv")
+	if err := os.WriteFile(long, []byte("a\tb\n"+strings.Repeat("x", 70000)+"\ty\n1\t2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`%s readFile tsv each(len)`, strconv.Quote(long)),
+		`Arr<Num...>`,
+		`[2, 2, 2]`,
+		nil,
+		t,
+	)
+	// read errors
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`%s readFile tsv`, strconv.Quote(dir)),
+		``,
+		``,
+		errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+		),
+		t,
+	)
+}
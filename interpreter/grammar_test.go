@@ -3,6 +3,8 @@ package interpreter_test
 import (
 	"testing"
 
+	"github.com/alecthomas/participle/lexer"
+
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/interpreter"
 	"github.com/texttheater/bach/states"
@@ -29,3 +31,37 @@ func TestGrammar(t *testing.T) {
 		t,
 	)
 }
+
+func TestComments(t *testing.T) {
+	interpreter.TestProgram(
+		"# a comment\n1 # another comment\n+2 #",
+		types.Num{},
+		states.NumValue(3),
+		nil,
+		t,
+	)
+	interpreter.TestProgram(
+		`"a#b" # the first # is part of the string`,
+		types.Str{},
+		states.StrValue("a#b"),
+		nil,
+		t,
+	)
+	interpreter.TestProgram(
+		"\"{1 # comment in placeholder\n}\"",
+		types.Str{},
+		states.StrValue("1"),
+		nil,
+		t,
+	)
+	interpreter.TestProgram(
+		"# a comment\n  &",
+		nil,
+		nil,
+		errors.SyntaxError(
+			errors.Code(errors.Syntax),
+			errors.Pos(lexer.Position{Offset: 14, Line: 2, Column: 3}),
+		),
+		t,
+	)
+}
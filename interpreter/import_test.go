@@ -0,0 +1,94 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/interpreter"
+	"github.com/texttheater/bach/states"
+)
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	for name, program := range map[string]string{
+		"lib/util.bach": "for Num def double Num as *2 ok\n10 =ten\n",
+		"lib.bach":      "import \"lib/util.bach\"\nfor Num def quadruple Num as double double ok\n",
+		"cycle1.bach":   "import \"cycle2.bach\"\n",
+		"cycle2.bach":   "import \"cycle1.bach\"\n",
+		"bad.bach":      "for Num def f Str as 1 ok\n",
+		"fatal.bach":    "for Num def f Num as +1 ok\n\"boom\" fatal\n",
+	} {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(program), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main := filepath.Join(dir, "main.bach")
+	// imports are relative to the importing file, definitions and
+	// variables are available after importing
+	_, val, err := interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "lib.bach" 3 quadruple +ten`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal, err := val.Equal(states.NumValue(22))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Fatalf("unexpected value %s", val)
+	}
+	// errors
+	_, _, err = interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "cycle1.bach"`)
+	if !errors.Match(errors.SyntaxError(errors.Code(errors.ImportCycle)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, _, err = interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "missing.bach"`)
+	if !errors.Match(errors.SyntaxError(errors.Code(errors.ImportFailed)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, _, err = interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "bad.bach"`)
+	if !errors.Match(errors.TypeError(errors.Code(errors.FunctionBodyHasWrongOutputType)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if e := err.(*errors.E); e.Pos.Filename != filepath.Join(dir, "bad.bach") {
+		t.Fatalf("unexpected error position %s", e.Pos)
+	}
+	// the offending code is shown from the imported file as it was parsed
+	if err := os.Remove(filepath.Join(dir, "bad.bach")); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	errors.Explain(&buffer, err, `import "bad.bach"`)
+	if !strings.Contains(buffer.String(), "1 | for Num def f Str as 1 ok\n") {
+		t.Fatalf("unexpected explanation:\n%s", buffer.String())
+	}
+	// runtime errors in imported files are not ignored, even if the
+	// importing program ignores its input
+	_, _, err = interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "fatal.bach" 1 f`)
+	if !errors.Match(errors.ValueError(errors.Code(errors.UnexpectedValue)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if e := err.(*errors.E); e.Pos.Filename != filepath.Join(dir, "fatal.bach") {
+		t.Fatalf("unexpected error position %s", e.Pos)
+	}
+	// side effects of imported files happen
+	out := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(filepath.Join(dir, "write.bach"), []byte(`"hi" writeFile("`+out+`")`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = interpreter.InterpretFile(builtin.InitialShape, states.InitialState, main, `import "write.bach" 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(out); err != nil || string(content) != "hi" {
+		t.Fatalf("unexpected file content %q, error %v", content, err)
+	}
+}
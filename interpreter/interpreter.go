@@ -2,6 +2,7 @@ package interpreter
 
 import (
 	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/expressions"
 	"github.com/texttheater/bach/grammar"
 	"github.com/texttheater/bach/shapes"
 	"github.com/texttheater/bach/states"
@@ -11,26 +12,106 @@ import (
 // InterpretString takes a Bach program as a string, interprets it and returns
 // the result type and value.
 func InterpretString(inputShape shapes.Shape, inputState states.State, program string) (types.Type, states.Value, error) {
-	// parse
-	x, err := grammar.ParseComposition(program)
+	outputShape, _, val, err := interpret(inputShape, inputState, "", program)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputShape.Type, val, nil
+}
+
+// InterpretFile is like InterpretString, but takes the name of the file the
+// program was read from, for use in error positions.
+func InterpretFile(inputShape shapes.Shape, inputState states.State, filename string, program string) (types.Type, states.Value, error) {
+	outputShape, _, val, err := interpret(inputShape, inputState, filename, program)
 	if err != nil {
 		return nil, nil, err
 	}
+	return outputShape.Type, val, nil
+}
+
+// InterpretStringState is like InterpretString, but returns the whole output
+// shape and output state rather than just the output type. They contain the
+// funcers and variables defined by the program, and can be used as input to
+// another program, e.g., in a REPL session.
+func InterpretStringState(inputShape shapes.Shape, inputState states.State, program string) (shapes.Shape, states.State, states.Value, error) {
+	return interpret(inputShape, inputState, "", program)
+}
+
+// InterpretFileState is like InterpretStringState, but takes the name of the
+// file the program was read from, for use in error positions.
+func InterpretFileState(inputShape shapes.Shape, inputState states.State, filename string, program string) (shapes.Shape, states.State, states.Value, error) {
+	return interpret(inputShape, inputState, filename, program)
+}
+
+// TypecheckString takes a Bach program as a string, parses and type-checks it
+// without evaluating it, and returns the result type.
+func TypecheckString(inputShape shapes.Shape, program string) (types.Type, error) {
+	return typecheckOnly(inputShape, "", program)
+}
+
+// TypecheckFile is like TypecheckString, but takes the name of the file the
+// program was read from, for use in error positions.
+func TypecheckFile(inputShape shapes.Shape, filename string, program string) (types.Type, error) {
+	return typecheckOnly(inputShape, filename, program)
+}
+
+func interpret(inputShape shapes.Shape, inputState states.State, filename string, program string) (shapes.Shape, states.State, states.Value, error) {
+	// parse
+	x, info, err := parse(filename, program)
+	if err != nil {
+		return shapes.Shape{}, states.State{}, nil, explainable(err, info)
+	}
+	// type-check
+	outputShape, action, err := typecheck(inputShape, x)
+	if err != nil {
+		return shapes.Shape{}, states.State{}, nil, explainable(err, info)
+	}
+	// evaluate
+	outputState := action(inputState, nil)
+	val, err := outputState.Thunk.Eval()
+	if err != nil {
+		return shapes.Shape{}, states.State{}, nil, explainable(err, info)
+	}
+	return outputShape, outputState, val, nil
+}
+
+func typecheckOnly(inputShape shapes.Shape, filename string, program string) (types.Type, error) {
+	// parse
+	x, info, err := parse(filename, program)
+	if err != nil {
+		return nil, explainable(err, info)
+	}
 	// type-check
+	outputShape, _, err := typecheck(inputShape, x)
+	if err != nil {
+		return nil, explainable(err, info)
+	}
+	return outputShape.Type, nil
+}
+
+func parse(filename string, program string) (expressions.Expression, *grammar.SourceInfo, error) {
+	if filename == "" {
+		return grammar.ParseComposition(program)
+	}
+	return grammar.ParseCompositionFile(filename, program)
+}
+
+// explainable adds information from parsing to an error so it can be explained
+// well.
+func explainable(err error, info *grammar.SourceInfo) error {
+	return errors.WithSources(errors.WithCallEnds(err, info.CallEnds), info.Sources)
+}
+
+func typecheck(inputShape shapes.Shape, x expressions.Expression) (shapes.Shape, states.Action, error) {
 	outputShape, action, _, err := x.Typecheck(inputShape, nil)
 	if err != nil {
-		return nil, nil, err
+		return shapes.Shape{}, nil, err
 	}
 	if (types.Void{}).Subsumes(outputShape.Type) {
-		return nil, nil, errors.TypeError(
+		return shapes.Shape{}, nil, errors.TypeError(
 			errors.Code(errors.VoidProgram),
 			errors.Pos(x.Position()),
 		)
 	}
-	// evaluate
-	val, err := action(inputState, nil).Thunk.Eval()
-	if err != nil {
-		return nil, nil, err
-	}
-	return outputShape.Type, val, err
+	return outputShape, action, nil
 }
@@ -2,8 +2,12 @@ package interpreter_test
 
 import (
 	"math"
+	"reflect"
 	"testing"
 
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/interpreter"
 	"github.com/texttheater/bach/states"
 	"github.com/texttheater/bach/types"
@@ -68,3 +72,59 @@ func TestRecursion(t *testing.T) {
 		t,
 	)
 }
+
+func TestRecursionTrace(t *testing.T) {
+	for _, test := range []struct {
+		program string
+		trace   []states.Frame
+	}{
+		// naive recursive factorial failing at the bottom
+		{
+			`for Num def fac Num as if ==0 then "x" parseInt else =n -1 fac *n ok ok 3 fac`,
+			[]states.Frame{
+				{Name: "fac", Pos: lexer.Position{Offset: 59, Line: 1, Column: 60}, Count: 3},
+				{Name: "fac", Pos: lexer.Position{Offset: 74, Line: 1, Column: 75}, Count: 1},
+			},
+		},
+		// tail recursion
+		{
+			`for Num def f Num as if ==0 then "x" parseInt else -1 f ok ok 10000 f`,
+			[]states.Frame{
+				{Name: "f", Pos: lexer.Position{Offset: 54, Line: 1, Column: 55}, Count: 10000},
+				{Name: "f", Pos: lexer.Position{Offset: 68, Line: 1, Column: 69}, Count: 1},
+			},
+		},
+		// callbacks
+		{
+			`[1, 0] each(if ==0 then "x" parseInt else 1 ok) toJSON`,
+			[]states.Frame{
+				{Name: "each", Pos: lexer.Position{Offset: 7, Line: 1, Column: 8}, Count: 1},
+			},
+		},
+		// a memoized error raised again as part of other calls keeps
+		// its frames and gets the new ones
+		{
+			`["x"] each(parseInt) =a a get(0) catch(null) =_ for Any def g Num as a get(0) ok g =_ g`,
+			[]states.Frame{
+				{Name: "each", Pos: lexer.Position{Offset: 6, Line: 1, Column: 7}, Count: 1},
+				{Name: "g", Pos: lexer.Position{Offset: 86, Line: 1, Column: 87}, Count: 1},
+			},
+		},
+	} {
+		_, _, err := interpreter.InterpretString(builtin.InitialShape, states.InitialState, test.program)
+		if !errors.Match(errors.ValueError(errors.Code(errors.UnexpectedValue)), err) {
+			t.Fatalf("%s: unexpected error %v", test.program, err)
+		}
+		if trace := err.(*errors.E).Trace; !reflect.DeepEqual(trace, test.trace) {
+			t.Fatalf("%s: unexpected trace %v", test.program, trace)
+		}
+	}
+	// long traces keep only the innermost calls
+	_, _, err := interpreter.InterpretString(builtin.InitialShape, states.InitialState, `for Num def f Num as if ==0 then "x" parseInt elif %2 ==0 then -1 f else -1 f ok ok 1000 f`)
+	if !errors.Match(errors.ValueError(errors.Code(errors.UnexpectedValue)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if trace := err.(*errors.E).Trace; len(trace) != 100 || trace[0].Pos.Column != 77 {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+}
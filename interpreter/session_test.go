@@ -0,0 +1,45 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/interpreter"
+	"github.com/texttheater/bach/shapes"
+	"github.com/texttheater/bach/states"
+	"github.com/texttheater/bach/types"
+)
+
+func TestInterpretStringState(t *testing.T) {
+	shape := builtin.InitialShape
+	state := states.InitialState
+	for _, program := range []string{
+		`for Num def double Num as *2 ok`,
+		`3 =x`,
+		`x double`,
+	} {
+		outputShape, outputState, _, err := interpreter.InterpretStringState(shape, state, program)
+		if err != nil {
+			t.Fatalf("%s: %v", program, err)
+		}
+		shape = shapes.Shape{
+			Type:  types.Null{},
+			Stack: outputShape.Stack,
+		}
+		state = outputState.Clear()
+	}
+	typ, val, err := interpreter.InterpretString(shape, state, `x double +x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !types.Equivalent(typ, types.Num{}) {
+		t.Fatalf("unexpected type %s", typ)
+	}
+	equal, err := val.Equal(states.NumValue(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Fatalf("unexpected value %s", val)
+	}
+}
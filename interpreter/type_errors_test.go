@@ -1,10 +1,17 @@
 package interpreter_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
 	"testing"
 
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/builtin"
+
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/interpreter"
+	"github.com/texttheater/bach/states"
 	"github.com/texttheater/bach/types"
 )
 
@@ -43,3 +50,200 @@ func TestTypeErrors(t *testing.T) {
 		t,
 	)
 }
+
+func TestNoSuchFuncerHints(t *testing.T) {
+	interpreter.TestProgramStr(
+		`1 len`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.NoSuchFuncer),
+			errors.Hint("`len` with no parameters exists for input type `Arr<Any...>`, but the input has type `Num`."),
+		),
+		t,
+	)
+	interpreter.TestProgramStr(
+		`["a", "b"] join("b", "c")`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.NoSuchFuncer),
+			errors.Hint("`join` exists with 0 or 1 parameters, but is called with 2 parameters here."),
+		),
+		t,
+	)
+	interpreter.TestProgramStr(
+		`"abc" toupper`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.NoSuchFuncer),
+			errors.Hint("Did you mean `toUpper`?"),
+		),
+		t,
+	)
+	interpreter.TestProgramStr(
+		`for Num def double Num as *2 ok 1 dubble`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.NoSuchFuncer),
+			errors.Hint("Did you mean `double`?"),
+		),
+		t,
+	)
+	_, err := interpreter.TypecheckString(builtin.InitialShape, `hurz`)
+	if e, ok := err.(*errors.E); !ok || e.Hint != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTypecheckOnly(t *testing.T) {
+	// the program would fail at runtime, but typechecking succeeds
+	typ, err := interpreter.TypecheckString(builtin.InitialShape, `1 if ==1 then fatal else true ok`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !types.Equivalent(typ, types.Bool{}) {
+		t.Fatalf("unexpected type %s", typ)
+	}
+	_, err = interpreter.TypecheckString(builtin.InitialShape, `3 <2 +1`)
+	if !errors.Match(errors.TypeError(errors.Code(errors.NoSuchFuncer)), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestErrorSpans(t *testing.T) {
+	interpreter.TestProgramStr(
+		`null foo(1, 2)  # comment`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.NoSuchFuncer),
+			errors.Span(
+				lexer.Position{Offset: 5, Line: 1, Column: 6},
+				lexer.Position{Offset: 14, Line: 1, Column: 15},
+			),
+		),
+		t,
+	)
+	interpreter.TestProgramStr(
+		`1 +"a"`,
+		``,
+		``,
+		errors.TypeError(
+			errors.Code(errors.ArgHasWrongOutputType),
+			errors.Span(
+				lexer.Position{Offset: 2, Line: 1, Column: 3},
+				lexer.Position{Offset: 6, Line: 1, Column: 7},
+			),
+		),
+		t,
+	)
+	// value errors raised by funcers span the call
+	interpreter.TestProgramStr(
+		`[1, 2] get(5)`,
+		``,
+		``,
+		errors.ValueError(
+			errors.Code(errors.NoSuchIndex),
+			errors.Span(
+				lexer.Position{Offset: 7, Line: 1, Column: 8},
+				lexer.Position{Offset: 13, Line: 1, Column: 14},
+			),
+		),
+		t,
+	)
+	program := "for Num def f Num as\n  \"x\"\nok\n1 f"
+	_, err := interpreter.TypecheckString(builtin.InitialShape, program)
+	if !errors.Match(errors.TypeError(
+		errors.Code(errors.FunctionBodyHasWrongOutputType),
+		errors.Span(
+			lexer.Position{Offset: 0, Line: 1, Column: 1},
+			lexer.Position{Offset: 29, Line: 3, Column: 3},
+		),
+	), err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var buffer bytes.Buffer
+	errors.Explain(&buffer, err, program)
+	want := `Type error at 1:1
+1 | for Num def f Num as
+  | ^~~~~~~~~~~~~~~~~~~~
+2 |   "x"
+  |   ~~~
+3 | ok
+  | ~~
+Code:       FunctionBodyHasWrongOutputType
+Message:    The function body has the wrong output type.
+Want type:  Num
+Got type:   Str
+`
+	if buffer.String() != want {
+		t.Fatalf("unexpected explanation:\n%s", buffer.String())
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	for _, test := range []struct {
+		program string
+		want    map[string]any
+	}{
+		{
+			`1 +"a"`,
+			map[string]any{
+				"kind":      "Type",
+				"code":      "ArgHasWrongOutputType",
+				"message":   "An argument has the wrong output type.",
+				"line":      1.0,
+				"column":    3.0,
+				"offset":    2.0,
+				"endLine":   1.0,
+				"endColumn": 7.0,
+				"endOffset": 6.0,
+				"wantType":  "Num",
+				"gotType":   "Str",
+				"argNum":    1.0,
+			},
+		},
+		{
+			`for Num def f Num as if ==1 then fatal(7) else 1 ok ok 1 f`,
+			map[string]any{
+				"kind":      "Value",
+				"code":      "UnexpectedValue",
+				"message":   "Component got an unexpected input value.",
+				"line":      1.0,
+				"column":    34.0,
+				"offset":    33.0,
+				"endLine":   1.0,
+				"endColumn": 42.0,
+				"endOffset": 41.0,
+				"gotValue":  "1",
+				"exitCode":  7.0,
+				"trace": []any{
+					map[string]any{
+						"name":   "f",
+						"line":   1.0,
+						"column": 58.0,
+						"offset": 57.0,
+						"count":  1.0,
+					},
+				},
+			},
+		},
+	} {
+		_, _, err := interpreter.InterpretString(builtin.InitialShape, states.InitialState, test.program)
+		if err == nil {
+			t.Fatalf("%s: expected an error", test.program)
+		}
+		var buffer bytes.Buffer
+		errors.ExplainJSON(&buffer, err)
+		var got map[string]any
+		if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+			t.Fatalf("%s: invalid JSON %q: %v", test.program, buffer.String(), err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("%s: unexpected JSON %s", test.program, buffer.String())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/interpreter"
+	"github.com/texttheater/bach/states"
+)
+
+func TestWriters(t *testing.T) {
+	// capture STDOUT and STDERR
+	var stdout, stderr bytes.Buffer
+	state := builtin.BindOutput(states.InitialState, &stdout, &stderr)
+	_, _, err := interpreter.InterpretString(builtin.InitialShape, state, `"a" out =_ "b" write(stdout) =_ for Str def warn Null as err("") ok "1" warn =_ "c" write(stderr)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "a\nb\n" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	if stderr.String() != "1c\n" {
+		t.Fatalf("unexpected error output %q", stderr.String())
+	}
+	// write to a file
+	file := strconv.Quote(filepath.Join(t.TempDir(), "file.txt"))
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`%s fileWriter =w "a" write(w) =_ w close =_ %s fileAppender =w 1 write(w, "") =_ w close =_ %s readText`, file, file, file),
+		`Str`,
+		`"a\n1"`,
+		nil,
+		t,
+	)
+	// closed files can no longer be written to
+	interpreter.TestProgramStr(
+		fmt.Sprintf(`%s fileWriter =w w close =_ "a" write(w)`, file),
+		``,
+		``,
+		errors.ValueError(
+			errors.Code(errors.UnexpectedValue),
+		),
+		t,
+	)
+}
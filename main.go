@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kong"
 	"github.com/alecthomas/participle/lexer"
-	"github.com/c-bata/go-prompt"
 	"github.com/texttheater/bach/builtin"
 	"github.com/texttheater/bach/errors"
 	"github.com/texttheater/bach/interpreter"
@@ -15,13 +18,27 @@ import (
 )
 
 var cli struct {
-	Program string `arg:"" optional:"" help:"Program to execute. If not provided, Bach's REPL will be started."`
+	Program string   `arg:"" optional:"" help:"Program to execute. If not provided, Bach's REPL will be started."`
+	Args    []string `arg:"" optional:"" passthrough:"" help:"Arguments to pass to the program."`
 
-	Quiet bool `short:"q" help:"Do not print the output value of the program."`
+	File        bool   `short:"f" help:"Read the program from the file named by the Program argument. Lets you write scripts starting with #!/usr/bin/env -S bach -f"`
+	Output      string `short:"o" enum:"str,repr,json,ndjson,pretty-json" default:"str" help:"How to print output values. str: as strings, repr: as Bach literals, json: as JSON, ndjson: as JSON, one value per line, pretty-json: as indented JSON. If the program returns an array, its elements are printed as they are computed."`
+	Input       string `short:"i" enum:"lines,json,text,csv,null" default:"lines" help:"How to read STDIN. lines: as an array of lines (Arr<Str...>), json: as a stream of JSON values (Arr<Any...>), text: as a single string (Str), csv: as an array of CSV records (Arr<Arr<Str...>...>), null: not at all (Null)."`
+	ErrorFormat string `enum:"text,json" default:"text" help:"How to report errors on STDERR. text: human-readable, json: as a single line of JSON with the properties kind, code, message, file, line, column, offset, endLine, endColumn, endOffset, wantType, gotType, gotValue, inputType, name, argNum, numParams, paramNum, wantParam, gotParam, hint, exitCode, and trace."`
+	Check       bool   `short:"c" help:"Only parse and typecheck the program, do not run it."`
+	Type        bool   `short:"t" help:"Print the output type of the program instead of running it."`
+	Quiet       bool   `short:"q" help:"Do not print the output value of the program."`
+
+	Arg     strVariables  `placeholder:"NAME VALUE" help:"Bind the variable NAME to the string VALUE. Can be repeated."`
+	ArgJSON jsonVariables `name:"argjson" placeholder:"NAME JSON" help:"Bind the variable NAME to the value of the JSON text JSON. Can be repeated."`
 }
 
+// programFileExitStatus is the exit status when the file given with -f cannot
+// be read. It differs from the exit statuses for Bach errors.
+const programFileExitStatus = 5
+
 func main() {
-	kong.Parse(
+	ctx := kong.Parse(
 		&cli,
 		kong.Name("bach"),
 		kong.Description("An interpreter for the Bach programming language."),
@@ -31,82 +48,206 @@ func main() {
 		repl()
 		os.Exit(0)
 	}
-	// execute program given on command line
-	success := executeCLI(cli.Program)
-	if !success {
-		os.Exit(1)
+	// read program from file
+	filename := ""
+	program := cli.Program
+	if cli.File {
+		content, err := os.ReadFile(cli.Program)
+		if err != nil {
+			ctx.Errorf("%s", err)
+			os.Exit(programFileExitStatus)
+		}
+		filename = cli.Program
+		program = string(content)
 	}
+	// typecheck or execute program
+	var exitStatus int
+	if cli.Check || cli.Type {
+		exitStatus = typecheckCLI(filename, program)
+	} else {
+		exitStatus = executeCLI(filename, program)
+	}
+	os.Exit(exitStatus)
 }
 
-func repl() {
-	p := prompt.New(func(program string) {
-		execute(program)
-	}, func(prompt.Document) []prompt.Suggest {
-		return nil
-	},
-		prompt.OptionPrefix("bach> "))
-	p.Run()
-}
-
-func execute(program string) (success bool) {
-	_, value, err := interpreter.InterpretString(builtin.InitialShape, states.InitialState, program)
+// executeCLI runs a program on the lines of STDIN and prints its output. If the
+// program was read from a file, filename is its name, otherwise it is empty.
+func executeCLI(filename string, program string) (exitStatus int) {
+	initialShape, initialState := bindVariables(builtin.InitialShape, states.InitialState)
+	initialShape.Type, initialState.Thunk = cliInput(cli.Input)
+	var typ types.Type
+	var val states.Value
+	var err error
+	if filename == "" {
+		typ, val, err = interpreter.InterpretString(initialShape, initialState, program)
+	} else {
+		typ, val, err = interpreter.InterpretFile(initialShape, initialState, filename, program)
+	}
 	if err != nil {
-		errors.Explain(os.Stderr, err, program)
-		return false
+		return fail(err, program)
 	}
-	if !printValue(value, program) {
-		return false
+	if (types.Null{}).Subsumes(typ) {
+		// print nothing, except in JSON modes where output must be JSON
+		switch cli.Output {
+		case "json", "ndjson", "pretty-json":
+			fmt.Println("null")
+		}
+		return 0
 	}
-	return true
-}
-
-func executeCLI(program string) (success bool) {
-	initialShape := builtin.InitialShape
-	initialShape.Type = types.NewArr(types.Str{})
-	initialState := states.InitialState
-	initialState.Thunk = states.ThunkFromValue(states.ReaderValue{Reader: os.Stdin})
-	initialState.Thunk = builtin.Lines(initialState, nil, nil, lexer.Position{})
-	typ, val, err := interpreter.InterpretString(initialShape, initialState, program)
-	if err != nil {
-		errors.Explain(os.Stderr, err, program)
-		return false
+	if !types.AnyArr.Subsumes(typ) {
+		str, err := formatValue(val, cli.Output, "")
+		if err != nil {
+			return fail(err, program)
+		}
+		fmt.Println(str)
+		return 0
 	}
-	if types.AnyArr.Subsumes(typ) {
-		iter := states.IterFromThunk(states.ThunkFromValue(val))
-		for {
-			thk, ok, err := iter()
-			if err != nil {
-				errors.Explain(os.Stderr, err, program)
-				return false
-			}
-			if !ok {
-				break
+	// print array elements one by one
+	if cli.Output == "json" || cli.Output == "pretty-json" {
+		fmt.Print("[")
+	}
+	first := true
+	closeArr := func() {
+		switch cli.Output {
+		case "json":
+			fmt.Println("]")
+		case "pretty-json":
+			if !first {
+				fmt.Println()
 			}
-			val, err := thk.Eval()
-			if err != nil {
-				errors.Explain(os.Stderr, err, program)
-				return false
+			fmt.Println("]")
+		}
+	}
+	iter := states.IterFromThunk(states.ThunkFromValue(val))
+	for {
+		thk, ok, err := iter()
+		if err != nil {
+			// keep the output valid JSON
+			closeArr()
+			return fail(err, program)
+		}
+		if !ok {
+			break
+		}
+		val, err := thk.Eval()
+		if err != nil {
+			closeArr()
+			return fail(err, program)
+		}
+		var str string
+		switch cli.Output {
+		case "json":
+			str, err = formatValue(val, "json", "")
+			if !first {
+				str = "," + str
 			}
-			if !printValue(val, program) {
-				return false
+		case "pretty-json":
+			str, err = formatValue(val, "pretty-json", "  ")
+			if first {
+				str = "\n  " + str
+			} else {
+				str = ",\n  " + str
 			}
+		default:
+			str, err = formatValue(val, cli.Output, "")
+			str = str + "\n"
 		}
-	} else if (types.Null{}).Subsumes(typ) {
-		// do nothing
-	} else {
-		if !printValue(val, program) {
-			return false
+		if err != nil {
+			closeArr()
+			return fail(err, program)
 		}
+		fmt.Print(str)
+		first = false
 	}
-	return true
+	closeArr()
+	return 0
 }
 
-func printValue(value states.Value, program string) (success bool) {
-	str, err := value.Str()
+// typecheckCLI typechecks a program without running it and, if the --type
+// option is given, prints its output type.
+func typecheckCLI(filename string, program string) (exitStatus int) {
+	initialShape, _ := bindVariables(builtin.InitialShape, states.InitialState)
+	initialShape.Type, _ = cliInput(cli.Input)
+	var typ types.Type
+	var err error
+	if filename == "" {
+		typ, err = interpreter.TypecheckString(initialShape, program)
+	} else {
+		typ, err = interpreter.TypecheckFile(initialShape, filename, program)
+	}
 	if err != nil {
+		return fail(err, program)
+	}
+	if cli.Type {
+		fmt.Println(typ)
+	}
+	return 0
+}
+
+// fail explains an error on STDERR and returns the exit status for it.
+func fail(err error, program string) (exitStatus int) {
+	if cli.ErrorFormat == "json" {
+		errors.ExplainJSON(os.Stderr, err)
+	} else {
 		errors.Explain(os.Stderr, err, program)
-		return false
 	}
-	fmt.Println(str)
-	return true
+	return errors.ExitStatus(err)
+}
+
+// cliInput returns the type and value of the program input for the given
+// input mode.
+func cliInput(mode string) (types.Type, *states.Thunk) {
+	stdinState := states.InitialState.Replace(
+		states.ThunkFromValue(states.ReaderValue{Reader: os.Stdin}),
+	)
+	switch mode {
+	case "json":
+		return types.AnyArr, builtin.JSON(stdinState, nil, nil, lexer.Position{})
+	case "text":
+		return types.Str{}, states.ThunkFromFunc(func() *states.Thunk {
+			text, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return states.ThunkFromError(err)
+			}
+			return states.ThunkFromValue(states.StrValue(text))
+		})
+	case "csv":
+		return types.NewArr(types.NewArr(types.Str{})), builtin.CSV(stdinState, nil, nil, lexer.Position{})
+	case "null":
+		return types.Null{}, states.ThunkFromValue(states.NullValue{})
+	default:
+		return types.NewArr(types.Str{}), builtin.Lines(stdinState, nil, nil, lexer.Position{})
+	}
+}
+
+// formatValue returns the string representation of a value for the given
+// output mode. In pretty-json mode, every line but the first is prefixed with
+// prefix.
+func formatValue(value states.Value, mode string, prefix string) (string, error) {
+	switch mode {
+	case "repr":
+		return value.Repr()
+	case "json", "ndjson", "pretty-json":
+		data, err := builtin.JSONData(value, lexer.Position{})
+		if err != nil {
+			return "", err
+		}
+		buffer := bytes.Buffer{}
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if mode == "pretty-json" {
+			encoder.SetIndent(prefix, "  ")
+		}
+		err = encoder.Encode(data)
+		if err != nil {
+			return "", errors.ValueError(
+				errors.Code(errors.UnexpectedValue),
+				errors.GotValue(value),
+				errors.Message(err.Error()),
+			)
+		}
+		return strings.TrimSuffix(buffer.String(), "\n"), nil
+	default:
+		return value.Str()
+	}
 }
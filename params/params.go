@@ -12,6 +12,10 @@ type Param struct {
 	Description string
 	Params      []*Param
 	OutputType  types.Type
+	// Callback is true if the funcer calls the argument with inputs of its
+	// own choosing, like each calls its argument for every element. Such
+	// calls are recorded in the call traces of errors.
+	Callback bool
 }
 
 func SimpleParam(Name string, Description string, outputType types.Type) *Param {
@@ -61,6 +65,7 @@ func (p *Param) Instantiate(bindings map[string]types.Type) *Param {
 		Name:       p.Name,
 		Params:     params,
 		OutputType: outputType,
+		Callback:   p.Callback,
 	}
 }
 
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/errors"
+	"github.com/texttheater/bach/grammar"
+	"github.com/texttheater/bach/interpreter"
+	"github.com/texttheater/bach/shapes"
+	"github.com/texttheater/bach/states"
+	"github.com/texttheater/bach/types"
+)
+
+// wordSeparator contains the characters that can precede a funcer name in a
+// program, for the purposes of tab completion.
+const wordSeparator = " \t\n()[]{},;|:@=+-*/%<>~\""
+
+var lidRegexp = regexp.MustCompile(`^[\p{Ll}_][\p{L}_0-9]*$`)
+
+// session holds what carries over from one line of a REPL session to the
+// next: the funcers and variables defined so far.
+type session struct {
+	shape shapes.Shape
+	state states.State
+}
+
+func newSession() *session {
+	shape, state := bindVariables(builtin.InitialShape, states.InitialState)
+	return &session{
+		shape: shape,
+		state: state,
+	}
+}
+
+// historySize is the maximum number of lines loaded from the history file.
+const historySize = 1000
+
+func repl() {
+	s := newSession()
+	// pending holds the previous lines of an incomplete program
+	pending := ""
+	historyPath := historyFile()
+	p := prompt.New(func(line string) {
+		appendHistory(historyPath, line)
+		program := pending + line
+		if pending == "" && strings.HasPrefix(strings.TrimSpace(program), ":") {
+			s = s.command(program)
+			return
+		}
+		if grammar.Incomplete(program) {
+			pending = program + "\n"
+			return
+		}
+		pending = ""
+		s.execute(program)
+	}, func(d prompt.Document) []prompt.Suggest {
+		return s.complete(pending, d)
+	},
+		prompt.OptionPrefix("bach> "),
+		prompt.OptionLivePrefix(func() (string, bool) {
+			return "....> ", pending != ""
+		}),
+		prompt.OptionHistory(loadHistory(historyPath)),
+		prompt.OptionAddKeyBind(prompt.KeyBind{
+			// Ctrl+C abandons an incomplete program
+			Key: prompt.ControlC,
+			Fn: func(*prompt.Buffer) {
+				pending = ""
+			},
+		}),
+		prompt.OptionCompletionWordSeparator(wordSeparator))
+	p.Run()
+}
+
+// historyFile returns the path of the file where REPL input is saved between
+// sessions, or the empty string if there is no user config directory.
+func historyFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "bach", "history")
+}
+
+// loadHistory reads the last lines of the history file. History is a
+// convenience, so errors are ignored.
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) > historySize {
+		lines = lines[len(lines)-historySize:]
+	}
+	return lines
+}
+
+// appendHistory adds a line to the history file, creating it if needed.
+// Errors are ignored.
+func appendHistory(path string, line string) {
+	if path == "" || strings.TrimSpace(line) == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// execute runs a program with the input shape and state of the session. If
+// it succeeds, the funcers and variables it defines are kept for subsequent
+// programs.
+func (s *session) execute(program string) (success bool) {
+	outputShape, outputState, value, err := interpreter.InterpretStringState(s.shape, s.state, program)
+	if err != nil {
+		errors.Explain(os.Stderr, err, program)
+		return false
+	}
+	if !printValue(value, program) {
+		return false
+	}
+	s.update(outputShape, outputState)
+	return true
+}
+
+// update makes the funcers and variables defined in an output shape and
+// output state available to subsequent programs.
+func (s *session) update(outputShape shapes.Shape, outputState states.State) {
+	s.shape = shapes.Shape{
+		Type:  types.Null{},
+		Stack: outputShape.Stack,
+	}
+	s.state = outputState.Clear()
+}
+
+// commands lists the REPL's meta-commands with their descriptions, for help
+// and tab completion.
+var commands = []prompt.Suggest{
+	{Text: ":doc", Description: "Show documentation for funcers with a name"},
+	{Text: ":funcers", Description: "List funcers, optionally only those for an input type"},
+	{Text: ":load", Description: "Evaluate a script file into the session"},
+	{Text: ":reset", Description: "Forget all definitions and variables"},
+	{Text: ":type", Description: "Show the type of a program without evaluating it"},
+}
+
+// command executes a REPL meta-command and returns the session to continue
+// with.
+func (s *session) command(line string) *session {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":doc":
+		s.doc(arg)
+	case ":funcers":
+		s.funcers(arg)
+	case ":load":
+		s.load(arg)
+	case ":reset":
+		return newSession()
+	case ":type":
+		s.typ(arg)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %s, available commands are:\n", name)
+		for _, c := range commands {
+			fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.Text, c.Description)
+		}
+	}
+	return s
+}
+
+// typ prints the output type of a program without evaluating it.
+func (s *session) typ(program string) {
+	typ, err := interpreter.TypecheckString(s.shape, program)
+	if err != nil {
+		errors.Explain(os.Stderr, err, program)
+		return
+	}
+	fmt.Println(typ)
+}
+
+// doc prints the documentation of all funcers with the given name.
+func (s *session) doc(name string) {
+	found := false
+	for stack := s.shape.Stack; stack != nil; stack = stack.Tail {
+		funcer := stack.Head
+		if funcer.Name != name {
+			continue
+		}
+		if found {
+			fmt.Println()
+		}
+		found = true
+		fmt.Println(funcer.SignatureAsMarkdown())
+		if funcer.Summary != "" {
+			fmt.Printf("\n%s\n", funcer.Summary)
+		}
+		fmt.Println()
+		fmt.Printf("  Input:  %s  %s\n", funcer.InputType, funcer.InputDescription)
+		for i, param := range funcer.Params {
+			fmt.Printf("  Param #%d: %s  %s\n", i+1, param, param.Description)
+		}
+		fmt.Printf("  Output: %s  %s\n", funcer.OutputType, funcer.OutputDescription)
+		if funcer.Notes != "" {
+			fmt.Printf("\n%s\n", funcer.Notes)
+		}
+		if len(funcer.Examples) > 0 {
+			fmt.Printf("\nExamples:\n")
+			for _, example := range funcer.Examples {
+				fmt.Printf("\n  %s\n", example.Program)
+				if example.Error != nil {
+					var buf strings.Builder
+					errors.Explain(&buf, example.Error, example.Program)
+					fmt.Print(indent(buf.String(), "    "))
+					continue
+				}
+				fmt.Printf("    Type:  %s\n", example.OutputType)
+				fmt.Printf("    Value: %s\n", example.OutputValue)
+			}
+		}
+	}
+	if !found {
+		fmt.Fprintf(os.Stderr, "no funcer named %q\n", name)
+	}
+}
+
+// funcers prints the signatures of all funcers in the session. If a type is
+// given, only funcers accepting it as input type are listed.
+func (s *session) funcers(typeStr string) {
+	var inputType types.Type
+	if typeStr != "" {
+		var err error
+		inputType, err = grammar.ParseType(typeStr)
+		if err != nil {
+			errors.Explain(os.Stderr, err, typeStr)
+			return
+		}
+	}
+	seen := make(map[string]bool)
+	for stack := s.shape.Stack; stack != nil; stack = stack.Tail {
+		funcer := stack.Head
+		signature := funcer.SignatureAsMarkdown()
+		if seen[signature] {
+			continue
+		}
+		seen[signature] = true
+		if inputType != nil && !accepts(funcer, inputType) {
+			continue
+		}
+		fmt.Println(signature)
+	}
+}
+
+// load evaluates a script file, keeping the funcers and variables it defines
+// for subsequent programs.
+func (s *session) load(filename string) {
+	program, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	outputShape, outputState, _, err := interpreter.InterpretFileState(s.shape, s.state, filename, string(program))
+	if err != nil {
+		errors.Explain(os.Stderr, err, string(program))
+		return
+	}
+	s.update(outputShape, outputState)
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s string, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func printValue(value states.Value, program string) (success bool) {
+	str, err := formatValue(value, cli.Output, "")
+	if err != nil {
+		errors.Explain(os.Stderr, err, program)
+		return false
+	}
+	fmt.Println(str)
+	return true
+}
+
+// complete suggests names of funcers that can be called at the cursor
+// position. pending holds the previous lines of an incomplete program. If the
+// program before the funcer name typechecks, only funcers accepting its output
+// type are suggested, including funcers it or earlier lines of the session
+// define. Imported files are not read for this. At the start of a
+// meta-command, command names are suggested instead.
+func (s *session) complete(pending string, d prompt.Document) []prompt.Suggest {
+	word := d.GetWordBeforeCursorUntilSeparator(wordSeparator)
+	before := d.TextBeforeCursor()
+	before = pending + before[:len(before)-len(word)]
+	filterByType := true
+	if pending == "" && strings.HasPrefix(strings.TrimLeft(before, " "), ":") {
+		name, rest, found := strings.Cut(strings.TrimLeft(before, " "), " ")
+		if !found {
+			// the word separator includes the colon, so complete
+			// command names without it
+			var suggestions []prompt.Suggest
+			for _, c := range commands {
+				if strings.HasPrefix(c.Text, ":"+word) {
+					suggestions = append(suggestions, prompt.Suggest{
+						Text:        strings.TrimPrefix(c.Text, ":"),
+						Description: c.Description,
+					})
+				}
+			}
+			return suggestions
+		}
+		switch name {
+		case ":type":
+			before = rest
+		case ":doc":
+			before = ""
+			filterByType = false
+		default:
+			return nil
+		}
+	}
+	if !lidRegexp.MatchString(word) {
+		return nil
+	}
+	if strings.HasSuffix(before, "=") || strings.HasSuffix(before, "@") {
+		// assignment or getter, not a call
+		return nil
+	}
+	shape := s.shape
+	if strings.TrimSpace(before) != "" {
+		x, err := grammar.ParseCompositionWithoutImports(before)
+		if err == nil {
+			shape, _, _, err = x.Typecheck(s.shape, nil)
+		}
+		if err != nil {
+			shape = s.shape
+			filterByType = false
+		}
+	}
+	var suggestions []prompt.Suggest
+	seen := make(map[string]bool)
+	for stack := shape.Stack; stack != nil; stack = stack.Tail {
+		funcer := stack.Head
+		if seen[funcer.Name] || !strings.HasPrefix(funcer.Name, word) {
+			continue
+		}
+		if filterByType && !accepts(funcer, shape.Type) {
+			continue
+		}
+		seen[funcer.Name] = true
+		suggestions = append(suggestions, prompt.Suggest{
+			Text:        funcer.Name,
+			Description: funcer.Summary,
+		})
+	}
+	return suggestions
+}
+
+// accepts checks whether a funcer can be called with the given input type.
+func accepts(funcer shapes.Funcer, inputType types.Type) bool {
+	bindings := make(map[string]types.Type)
+	if !funcer.InputType.Bind(inputType, bindings) {
+		return false
+	}
+	return funcer.InputType.Instantiate(bindings).Subsumes(inputType)
+}
@@ -8,14 +8,37 @@ type Thunk struct {
 	Func  func() *Thunk
 	Value Value
 	Error error
+	// Call is the call as part of which the thunk is evaluated, if any.
+	Call *Frame
 }
 
+// Eval evaluates the thunk and memoizes the result. If the evaluation fails
+// with a TraceableError, the calls as part of which the thunk and the thunks it
+// returns are evaluated are added to the trace. A memoized error keeps its
+// trace, and if it is raised again as part of other calls, those are added.
 func (t *Thunk) Eval() (Value, error) {
+	if t.Func == nil {
+		return t.Value, t.Error
+	}
+	// calls as part of which the evaluation happens, outermost first,
+	// only used if an error occurs
+	var calls []Frame
+	if t.Call != nil {
+		calls = pushCall(calls, *t.Call)
+	}
 	for t.Func != nil {
 		next := t.Func()
 		t.Func = next.Func
 		t.Value = next.Value
 		t.Error = next.Error
+		if next.Call != nil {
+			calls = pushCall(calls, *next.Call)
+		}
+	}
+	if calls != nil {
+		if err, ok := t.Error.(TraceableError); ok {
+			t.Error = err.WithTrace(addOuterCalls(err.CallTrace(), calls))
+		}
 	}
 	return t.Value, t.Error
 }
@@ -76,6 +99,14 @@ func (t *Thunk) EvalReader() (io.Reader, error) {
 	return val.(ReaderValue).Reader, nil
 }
 
+func (t *Thunk) EvalWriter() (io.Writer, error) {
+	val, err := t.Eval()
+	if err != nil {
+		return nil, err
+	}
+	return val.(WriterValue).Writer, nil
+}
+
 func ThunkFromFunc(fun func() *Thunk) *Thunk {
 	return &Thunk{
 		Func: fun,
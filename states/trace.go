@@ -0,0 +1,89 @@
+package states
+
+import (
+	"github.com/alecthomas/participle/lexer"
+)
+
+// maxTraceLen is the maximum number of frames in a call trace. When a trace
+// grows longer, only the innermost frames are kept.
+const maxTraceLen = 100
+
+// A Frame records a call of a funcer, for call traces in error messages. Pos
+// is the position of the call. Count is the number of times the call occurs
+// in a row, e.g., because of recursion.
+type Frame struct {
+	Name  string
+	Pos   lexer.Position
+	Count int
+}
+
+// A TraceableError is an error that can record the calls during which it
+// occurred.
+type TraceableError interface {
+	error
+	// CallTrace returns the calls during which the error occurred,
+	// innermost first.
+	CallTrace() []Frame
+	// WithTrace returns a copy of the error with the given call trace,
+	// innermost first.
+	WithTrace(trace []Frame) error
+}
+
+// ThunkInCall returns a thunk that evaluates to the same as the given thunk,
+// but records that the evaluation happens as part of a call of the named
+// funcer at the given position. If the evaluation results in a
+// TraceableError, the call is added to its trace.
+func ThunkInCall(thunk *Thunk, name string, pos lexer.Position) *Thunk {
+	// allocate the thunk and its frame together to keep calls cheap
+	t := &struct {
+		Thunk
+		frame Frame
+	}{
+		Thunk: Thunk{
+			Func: func() *Thunk {
+				return thunk
+			},
+		},
+		frame: Frame{
+			Name:  name,
+			Pos:   pos,
+			Count: 1,
+		},
+	}
+	t.Call = &t.frame
+	return &t.Thunk
+}
+
+// pushCall adds a call on the inside of calls, which are ordered outermost
+// first. Repeated calls are merged into one frame. To save memory, outer
+// frames are dropped from long traces.
+func pushCall(calls []Frame, call Frame) []Frame {
+	if n := len(calls); n > 0 && calls[n-1].Name == call.Name && calls[n-1].Pos == call.Pos {
+		calls[n-1].Count += call.Count
+		return calls
+	}
+	if len(calls) >= 2*maxTraceLen {
+		calls = append(calls[:0], calls[len(calls)-maxTraceLen+1:]...)
+	}
+	return append(calls, call)
+}
+
+// addOuterCalls returns a copy of trace, which is ordered innermost first,
+// with calls, which are ordered outermost first, added on the outside.
+// Repeated calls are merged into one frame. At most maxTraceLen frames are
+// kept.
+func addOuterCalls(trace []Frame, calls []Frame) []Frame {
+	result := make([]Frame, len(trace), len(trace)+len(calls))
+	copy(result, trace)
+	for i := len(calls) - 1; i >= 0; i-- {
+		if len(result) >= maxTraceLen {
+			break
+		}
+		if n := len(result); n > 0 && result[n-1].Name == calls[i].Name && result[n-1].Pos == calls[i].Pos {
+			result[n-1].Count += calls[i].Count
+			continue
+		}
+		result = append(result, calls[i])
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package states_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/states"
+)
+
+type tracedError struct {
+	trace []states.Frame
+}
+
+func (err tracedError) Error() string {
+	return "traced error"
+}
+
+func (err tracedError) CallTrace() []states.Frame {
+	return err.trace
+}
+
+func (err tracedError) WithTrace(trace []states.Frame) error {
+	return tracedError{trace}
+}
+
+func TestEvalTwice(t *testing.T) {
+	pos := lexer.Position{Offset: 2, Line: 1, Column: 3}
+	thunk := states.ThunkInCall(states.ThunkFromError(tracedError{}), "f", pos)
+	for i := 0; i < 2; i++ {
+		_, err := thunk.Eval()
+		trace := err.(tracedError).trace
+		if len(trace) != 1 || trace[0].Name != "f" || trace[0].Pos != pos || trace[0].Count != 1 {
+			t.Fatalf("evaluation %d: unexpected trace %v", i+1, trace)
+		}
+	}
+	// a memoized error raised again as part of another call gets its frame
+	outer := states.ThunkInCall(thunk, "g", pos)
+	_, err := outer.Eval()
+	trace := err.(tracedError).trace
+	if len(trace) != 2 || trace[0].Name != "f" || trace[1].Name != "g" {
+		t.Fatalf("unexpected trace %v", trace)
+	}
+}
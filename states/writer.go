@@ -0,0 +1,42 @@
+package states
+
+import (
+	"io"
+
+	"github.com/texttheater/bach/types"
+)
+
+type WriterValue struct {
+	Writer io.Writer
+}
+
+func (v WriterValue) Repr() (string, error) {
+	return "<writer>", nil
+}
+
+func (v WriterValue) Str() (string, error) {
+	return v.Repr()
+}
+
+func (v WriterValue) Data() (any, error) {
+	return v, nil
+}
+
+func (v WriterValue) Inhabits(t types.Type, stack *BindingStack) (bool, error) {
+	switch t := t.(type) {
+	case types.Writer:
+		return true, nil
+	case types.Union:
+		return inhabits(v, t, stack)
+	case types.Any:
+		return true, nil
+	case types.Var:
+		return stack.Inhabits(v, t)
+	default:
+		return false, nil
+	}
+}
+
+func (v WriterValue) Equal(w Value) (bool, error) {
+	return v == w, nil
+}
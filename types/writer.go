@@ -0,0 +1,53 @@
+package types
+
+type Writer struct {
+}
+
+func (t Writer) Subsumes(u Type) bool {
+	switch u.(type) {
+	case Void:
+		return true
+	case Writer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t Writer) Bind(u Type, bindings map[string]Type) bool {
+	switch u.(type) {
+	case Void:
+		return true
+	case Writer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t Writer) Instantiate(bindings map[string]Type) Type {
+	return t
+}
+
+func (t Writer) Partition(u Type) (Type, Type) {
+	switch u := u.(type) {
+	case Void:
+		return u, t
+	case Writer:
+		return u, Void{}
+	case Union:
+		return u.inversePartition(t)
+	case Any:
+		return t, Void{}
+	default:
+		return Void{}, t
+	}
+}
+
+func (t Writer) String() string {
+	return "Writer"
+}
+
+func (t Writer) ElementType() Type {
+	panic("Writer is not a sequence type")
+}
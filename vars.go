@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"github.com/alecthomas/participle/lexer"
+	"github.com/texttheater/bach/builtin"
+	"github.com/texttheater/bach/shapes"
+	"github.com/texttheater/bach/states"
+	"github.com/texttheater/bach/types"
+)
+
+// variable is a variable given on the command line.
+type variable struct {
+	name  string
+	typ   types.Type
+	thunk *states.Thunk
+}
+
+// strVariables is the type of the --arg option, which binds a variable to a
+// string. It takes the name and the value as two separate arguments.
+type strVariables []variable
+
+func (v *strVariables) Decode(ctx *kong.DecodeContext) error {
+	name, value, err := decodeVariable(ctx)
+	if err != nil {
+		return err
+	}
+	*v = append(*v, variable{
+		name:  name,
+		typ:   types.Str{},
+		thunk: states.ThunkFromValue(states.StrValue(value)),
+	})
+	return nil
+}
+
+// jsonVariables is the type of the --argjson option, which binds a variable
+// to a JSON value. It takes the name and the value as two separate arguments.
+type jsonVariables []variable
+
+func (v *jsonVariables) Decode(ctx *kong.DecodeContext) error {
+	name, value, err := decodeVariable(ctx)
+	if err != nil {
+		return err
+	}
+	var data any
+	err = json.Unmarshal([]byte(value), &data)
+	if err != nil {
+		return fmt.Errorf("invalid JSON value for variable %s: %w", name, err)
+	}
+	*v = append(*v, variable{
+		name:  name,
+		typ:   builtin.TypeFromData(data),
+		thunk: builtin.ThunkFromData(data, lexer.Position{}),
+	})
+	return nil
+}
+
+func decodeVariable(ctx *kong.DecodeContext) (name string, value string, err error) {
+	err = ctx.Scan.PopValueInto("name", &name)
+	if err != nil {
+		return "", "", err
+	}
+	if !lidRegexp.MatchString(name) {
+		return "", "", fmt.Errorf("invalid variable name %q", name)
+	}
+	err = ctx.Scan.PopValueInto("value", &value)
+	if err != nil {
+		return "", "", err
+	}
+	return name, value, nil
+}
+
+// bindVariables returns the given shape and state with the variables and the
+// program arguments from the command line added.
+func bindVariables(shape shapes.Shape, state states.State) (shapes.Shape, states.State) {
+	variables := append(append([]variable{}, cli.Arg...), cli.ArgJSON...)
+	for i := range variables {
+		// the address of the variable serves as its unique ID
+		id := &variables[i]
+		shape.Stack = shape.Stack.Push(shapes.VariableFuncer(id, id.name, id.typ))
+		state.Stack = state.Stack.Push(states.Variable{
+			ID:     id,
+			Action: states.SimpleAction(id.thunk),
+		})
+	}
+	state = builtin.BindArgs(state, cli.Args)
+	return shape, state
+}